@@ -1,31 +1,267 @@
 package main
 
 import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"api-monitor/internal/monitor"
 )
 
+// Exit codes follow the Nagios plugin conventions so certcheck can run from cron, CI or
+// a monitor exec probe.
+const (
+	exitOK       = 0
+	exitWarning  = 1
+	exitCritical = 2 // Also used for expired or unreachable targets
+	exitUsage    = 3
+)
+
+// maxDays is the longest threshold in days that fits in a time.Duration.
+const maxDays = int(math.MaxInt64 / int64(24*time.Hour))
+
+var statusNames = map[int]string{
+	exitOK:       "OK",
+	exitWarning:  "WARNING",
+	exitCritical: "CRITICAL",
+}
+
+// result is the outcome of checking one target. Thresholds apply to the certificate of
+// the chain that expires first, which may be an intermediate.
+type result struct {
+	Target   string    `json:"target"`
+	Status   string    `json:"status"`
+	NotAfter time.Time `json:"not_after,omitzero"`
+	DaysLeft float64   `json:"days_left"`
+	Subject  string    `json:"subject,omitempty"` // Certificate expiring first
+	Issuer   string    `json:"issuer,omitempty"`
+	Error    string    `json:"error,omitempty"`
+
+	code int
+}
+
 func main() {
-    timeout := flag.Duration("timeout", 5*time.Second, "Dial timeout for TLS connections")
-    flag.Parse()
-
-    targets := flag.Args()
-    if len(targets) == 0 {
-        fmt.Fprintln(os.Stderr, "Usage: certcheck [--timeout duration] host1 [host2 ...]")
-        os.Exit(2)
-    }
-
-    for _, t := range targets {
-        ttl, err := monitor.GetCertificateTTL(t, *timeout)
-        if err != nil {
-            fmt.Printf("%s -> ERROR: %v\n", t, err)
-            continue
-        }
-        expiry := time.Now().Add(ttl)
-        fmt.Printf("%s -> expires in %v (at %s)\n", t, ttl, expiry.Format(time.RFC3339))
-    }
+	timeout := flag.Duration("timeout", 5*time.Second, "Dial timeout for TLS connections")
+	output := flag.String("output", "table", "Output format: table, json or csv")
+	warn := flag.String("warn", "30d", "Exit with WARNING when a certificate expires within this duration (e.g. 30d, 72h)")
+	crit := flag.String("crit", "7d", "Exit with CRITICAL when a certificate expires within this duration")
+	file := flag.String("file", "", "Read targets from a file, one per line, or - for stdin")
+	workers := flag.Int("workers", 10, "Number of targets checked concurrently")
+	starttls := flag.String("starttls", "", "Upgrade host or host:port targets with STARTTLS: "+strings.Join(monitor.STARTTLSProtocols(), ", ")+
+		" (targets may also use a scheme such as smtp://host)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: certcheck [flags] [target1 target2 ...]")
+		fmt.Fprintln(os.Stderr, "Targets are https:// URLs, host or host:port, or STARTTLS URLs such as smtp://mail.example.com:587")
+		fmt.Fprintln(os.Stderr, "Exit codes: 0 OK, 1 WARNING, 2 CRITICAL (expiring, expired or unreachable), 3 usage error")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	warnAfter, err := parseDays(*warn)
+	if err != nil {
+		usageError("invalid --warn: %v", err)
+	}
+	critAfter, err := parseDays(*crit)
+	if err != nil {
+		usageError("invalid --crit: %v", err)
+	}
+	if critAfter > warnAfter {
+		usageError("--crit (%s) must not be longer than --warn (%s)", *crit, *warn)
+	}
+	if *workers < 1 {
+		usageError("--workers must be at least 1")
+	}
+	if _, err := monitor.WithSTARTTLS("", *starttls); err != nil {
+		usageError("invalid --starttls: %v", err)
+	}
+	if *output != "table" && *output != "json" && *output != "csv" {
+		usageError("unknown --output %q (expected table, json or csv)", *output)
+	}
+
+	targets := flag.Args()
+	if *file != "" {
+		fileTargets, err := readTargets(*file)
+		if err != nil {
+			usageError("%v", err)
+		}
+		targets = append(targets, fileTargets...)
+	}
+	if len(targets) == 0 {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	results := checkAll(targets, *starttls, *workers, *timeout, warnAfter, critAfter)
+	if err := writeResults(os.Stdout, *output, results); err != nil {
+		fmt.Fprintf(os.Stderr, "certcheck: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	code := exitOK
+	for _, r := range results {
+		code = max(code, r.code)
+	}
+	os.Exit(code)
+}
+
+// usageError reports a command-line problem and exits with exitUsage.
+func usageError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "certcheck: "+format+"\n", args...)
+	os.Exit(exitUsage)
+}
+
+// parseDays parses a Go duration, additionally accepting a whole number of days such as "30d".
+func parseDays(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 || n > maxDays {
+			return 0, fmt.Errorf("invalid number of days %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative, got %s", value)
+	}
+	return d, nil
+}
+
+// readTargets reads one target per line from path, or stdin when path is "-".
+// Blank lines and lines starting with # are skipped.
+func readTargets(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read targets: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var targets []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read targets: %w", err)
+	}
+	return targets, nil
+}
+
+// checkAll checks targets with the given number of workers and returns the results in
+// the order of targets.
+func checkAll(targets []string, starttls string, workers int, timeout, warnAfter, critAfter time.Duration) []result {
+	results := make([]result, len(targets))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(targets)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = check(targets[i], starttls, timeout, warnAfter, critAfter)
+			}
+		}()
+	}
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results
+}
+
+// check inspects the chain of target and grades its earliest expiry against the thresholds.
+func check(target, starttls string, timeout, warnAfter, critAfter time.Duration) result {
+	address, err := monitor.WithSTARTTLS(target, starttls)
+	var chain monitor.CertificateChain
+	if err == nil {
+		chain, err = monitor.InspectCertificateChain(address, timeout)
+	}
+	if err != nil {
+		return result{Target: target, Status: statusNames[exitCritical], Error: err.Error(), code: exitCritical}
+	}
+	return grade(target, chain, time.Now(), warnAfter, critAfter)
+}
+
+// grade rates the certificate of chain that expires first, as seen at now: CRITICAL once it
+// expires within critAfter or has expired, WARNING within warnAfter and OK otherwise.
+func grade(target string, chain monitor.CertificateChain, now time.Time, warnAfter, critAfter time.Duration) result {
+	r := result{Target: target, code: exitCritical}
+	earliest := chain.EarliestExpiry()
+	ttl := earliest.NotAfter.Sub(now)
+	r.NotAfter = earliest.NotAfter.UTC()
+	r.DaysLeft = float64(int(ttl.Hours()/24*10)) / 10 // One decimal, truncated
+	r.Subject, r.Issuer = earliest.Subject, earliest.Issuer
+	switch {
+	case ttl <= 0:
+		r.Error = "certificate expired"
+	case ttl <= critAfter:
+	case ttl <= warnAfter:
+		r.code = exitWarning
+	default:
+		r.code = exitOK
+	}
+	r.Status = statusNames[r.code]
+	return r
+}
+
+// writeResults renders results in the requested format.
+func writeResults(w io.Writer, format string, results []result) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"target", "status", "not_after", "days_left", "subject", "issuer", "error"})
+		for _, r := range results {
+			cw.Write([]string{r.Target, r.Status, formatTime(r.NotAfter), formatDays(r), r.Subject, r.Issuer, r.Error})
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TARGET\tSTATUS\tNOT AFTER\tDAYS LEFT\tSUBJECT\tERROR")
+		for _, r := range results {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Target, r.Status, formatTime(r.NotAfter), formatDays(r), r.Subject, r.Error)
+		}
+		return tw.Flush()
+	}
+}
+
+// formatTime renders t as RFC 3339, or an empty string for targets that could not be checked.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatDays renders the days left, or an empty string for targets that could not be checked.
+func formatDays(r result) string {
+	if r.NotAfter.IsZero() {
+		return ""
+	}
+	return strconv.FormatFloat(r.DaysLeft, 'f', 1, 64)
 }
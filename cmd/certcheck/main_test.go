@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"api-monitor/internal/monitor"
+)
+
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "0d", want: 0},
+		{value: "72h", want: 72 * time.Hour},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "-1d", wantErr: true},
+		{value: "-5h", wantErr: true},
+		{value: "1.5d", wantErr: true},
+		{value: "99999999d", wantErr: true}, // Overflows time.Duration
+		{value: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDays(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseDays(%q) = %v, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseDays(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestGrade(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	const day = 24 * time.Hour
+	leaf := func(ttl time.Duration) monitor.CertificateInfo {
+		return monitor.CertificateInfo{Subject: "CN=leaf", Issuer: "CN=ca", NotAfter: now.Add(ttl)}
+	}
+
+	tests := []struct {
+		name      string
+		chain     monitor.CertificateChain
+		wantCode  int
+		wantDays  float64
+		wantError string
+	}{
+		{name: "ok", chain: monitor.CertificateChain{leaf(90 * day)}, wantCode: exitOK, wantDays: 90},
+		{name: "warning", chain: monitor.CertificateChain{leaf(20 * day)}, wantCode: exitWarning, wantDays: 20},
+		{name: "warning at threshold", chain: monitor.CertificateChain{leaf(30 * day)}, wantCode: exitWarning, wantDays: 30},
+		{name: "critical", chain: monitor.CertificateChain{leaf(36 * time.Hour)}, wantCode: exitCritical, wantDays: 1.5},
+		{name: "expired", chain: monitor.CertificateChain{leaf(-2 * day)}, wantCode: exitCritical, wantDays: -2, wantError: "certificate expired"},
+		{
+			name: "intermediate expires first",
+			chain: monitor.CertificateChain{
+				leaf(90 * day),
+				{Subject: "CN=intermediate", Issuer: "CN=root", NotAfter: now.Add(5 * day)},
+			},
+			wantCode: exitCritical,
+			wantDays: 5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := grade("example.com", tt.chain, now, 30*day, 7*day)
+			if r.code != tt.wantCode || r.Status != statusNames[tt.wantCode] {
+				t.Errorf("code = %d (%s), want %d (%s)", r.code, r.Status, tt.wantCode, statusNames[tt.wantCode])
+			}
+			if r.DaysLeft != tt.wantDays || r.Error != tt.wantError {
+				t.Errorf("days left = %v, error = %q, want %v, %q", r.DaysLeft, r.Error, tt.wantDays, tt.wantError)
+			}
+			if want := tt.chain.EarliestExpiry(); r.Subject != want.Subject || !r.NotAfter.Equal(want.NotAfter) {
+				t.Errorf("graded %s (%v), want the earliest expiring %s", r.Subject, r.NotAfter, want.Subject)
+			}
+		})
+	}
+}
+
+func TestCheck_UnreachableIsCritical(t *testing.T) {
+	r := check("127.0.0.1:1", "", time.Second, 30*24*time.Hour, 7*24*time.Hour)
+	if r.code != exitCritical || r.Error == "" {
+		t.Fatalf("expected CRITICAL with an error, got %+v", r)
+	}
+}
+
+// testResults are one graded and one failed result, as written by every output format.
+var testResults = []result{
+	{
+		Target:   "example.com",
+		Status:   "WARNING",
+		NotAfter: time.Date(2026, 2, 1, 12, 0, 0, 0, time.UTC),
+		DaysLeft: 20.5,
+		Subject:  "CN=example.com",
+		Issuer:   "CN=Example CA",
+		code:     exitWarning,
+	},
+	{Target: "down.example.com", Status: "CRITICAL", Error: "failed to connect: refused", code: exitCritical},
+}
+
+func TestWriteResults_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeResults(&buf, "csv", testResults); err != nil {
+		t.Fatal(err)
+	}
+	want := "target,status,not_after,days_left,subject,issuer,error\n" +
+		"example.com,WARNING,2026-02-01T12:00:00Z,20.5,CN=example.com,CN=Example CA,\n" +
+		"down.example.com,CRITICAL,,,,,failed to connect: refused\n"
+	if buf.String() != want {
+		t.Fatalf("csv output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteResults_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeResults(&buf, "json", testResults); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json %q: %v", buf.String(), err)
+	}
+	want := []map[string]interface{}{
+		{
+			"target":    "example.com",
+			"status":    "WARNING",
+			"not_after": "2026-02-01T12:00:00Z",
+			"days_left": 20.5,
+			"subject":   "CN=example.com",
+			"issuer":    "CN=Example CA",
+		},
+		{"target": "down.example.com", "status": "CRITICAL", "days_left": 0.0, "error": "failed to connect: refused"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("json output = %v, want %v", got, want)
+	}
+}
+
+func TestWriteResults_Table(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeResults(&buf, "table", testResults); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and two rows, got:\n%s", buf.String())
+	}
+	for i, want := range [][]string{
+		{"TARGET", "STATUS", "NOT", "AFTER", "DAYS", "LEFT", "SUBJECT", "ERROR"},
+		{"example.com", "WARNING", "2026-02-01T12:00:00Z", "20.5", "CN=example.com"},
+		{"down.example.com", "CRITICAL", "failed", "to", "connect:", "refused"},
+	} {
+		if got := strings.Fields(lines[i]); !reflect.DeepEqual(got, want) {
+			t.Errorf("line %d = %q, want fields %q", i, lines[i], want)
+		}
+	}
+}
+
+func TestReadTargets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	data := "# production\nexample.com\n\n  api.example.com:8443  \n\t\n# mail\nsmtp://mail.example.com:587\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readTargets(path)
+	if err != nil {
+		t.Fatalf("readTargets failed: %v", err)
+	}
+	want := []string{"example.com", "api.example.com:8443", "smtp://mail.example.com:587"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("readTargets = %q, want %q", got, want)
+	}
+
+	if _, err := readTargets(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
@@ -1,103 +1,85 @@
 package monitor
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"os/exec"
-	"strings"
+	"io"
+	"net/http"
 	"sync"
 	"time"
 )
 
 // Configuration constants
 const (
-	aiHealthCheckURL   = "https://test-ai.com"
-	aiHealthCheckName  = "AIHealthCheck"
-	aiHealthCheckProxy = "http://proxy.example.com:3128"
+	aiHealthCheckURL          = "https://test-ai.com"
+	aiHealthCheckName         = "AIHealthCheck"
+	aiHealthCheckMaxRedirects = 10      // Redirects followed before the probe gives up
+	aiHealthCheckMaxBodyBytes = 1 << 20 // Response bytes read so latency covers the transfer
 )
 
 // StatusCheckProbe is an implementation of ProbeExecutor that checks HTTP status codes.
-// 2xx/3xx -> success (status=1), 4xx/5xx -> failure (status=0)
+// By default 2xx/3xx/400/401/403 -> success (status=1), anything else -> failure (status=0)
 type StatusCheckProbe struct {
-	URL  string
-	Name string
+	Client         *http.Client
+	URL            string
+	Name           string
+	ExpectedStatus *StatusMatcher
 }
 
 // NewStatusCheckProbe creates and returns a new StatusCheckProbe instance with TLS verification enabled.
-func NewStatusCheckProbe(targetURL, name string) *StatusCheckProbe {
-	FmtLog(LogLevelInfo, "Creating StatusCheckProbe: url=%s, name=%s, proxy=%s", targetURL, name, aiHealthCheckProxy)
+// Requests go through proxyConfig and follow up to aiHealthCheckMaxRedirects redirects.
+func NewStatusCheckProbe(targetURL, name string, proxyConfig ProxyConfig) *StatusCheckProbe {
+	FmtLog(LogLevelInfo, "Creating StatusCheckProbe: url=%s, name=%s, proxy=%s", targetURL, name, proxyConfig)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy, err := newProxyFunc(proxyConfig); err == nil {
+		transport.Proxy = proxy
+	} else {
+		FmtLog(LogLevelError, "Invalid AI health check proxy, connecting directly: %v", err)
+		transport.Proxy = nil
+	}
+
 	return &StatusCheckProbe{
-		URL:  targetURL,
-		Name: name,
+		Client: &http.Client{
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > aiHealthCheckMaxRedirects { // via holds every request made so far
+					return fmt.Errorf("stopped after %d redirects", aiHealthCheckMaxRedirects)
+				}
+				return nil
+			},
+		},
+		URL:            targetURL,
+		Name:           name,
+		ExpectedStatus: mustParseStatusMatcher(defaultHealthStatusRules),
 	}
 }
 
-// Execute implements the ProbeExecutor interface, performing an HTTP GET request via curl
-// and checking the status code: 2xx/3xx/400/401/403 -> 1, others -> 0.
+// Execute implements the ProbeExecutor interface, performing an HTTP GET request
+// and checking the final status code against ExpectedStatus.
 func (p *StatusCheckProbe) Execute(ctx context.Context) (ProbeResult, error) {
 	start := time.Now()
-	FmtLog(LogLevelInfo, "Starting curl request: url=%s", p.URL)
-
-	// Run curl command with proxy and verbose output
-	cmd := exec.CommandContext(ctx, "curl",
-		"-x", aiHealthCheckProxy,
-		"-o", "/dev/null",
-		"-s", "-v",
-		"-L",
-		p.URL,
-	)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return NewProbeResult(p.Name, 0, 0, 0, err), err
+	}
 
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		latency := time.Since(start).Seconds()
+		return NewProbeResult(p.Name, 0, latency, 0, err), err
+	}
+	defer resp.Body.Close()
 
-	err := cmd.Run()
+	// Read (a bounded amount of) the body so latency includes the transfer, as curl did
+	io.Copy(io.Discard, io.LimitReader(resp.Body, aiHealthCheckMaxBodyBytes))
 	latency := time.Since(start).Seconds()
-	stderrStr := stderr.String()
-
-	FmtLog(LogLevelInfo, "Curl stderr:\n%s", stderrStr)
-	FmtLog(LogLevelInfo, "Curl exit code: %v", err)
-
-	// Determine status from stderr
-	var status int
-	var effectiveStatusCode int
-
-	if strings.Contains(stderrStr, "403") {
-		status = 1
-		effectiveStatusCode = 403
-		FmtLog(LogLevelInfo, "Found 403 in output - PASS")
-	} else if strings.Contains(stderrStr, "401") {
-		status = 1
-		effectiveStatusCode = 401
-		FmtLog(LogLevelInfo, "Found 401 in output - PASS")
-	} else if strings.Contains(stderrStr, "400") {
-		status = 1
-		effectiveStatusCode = 400
-		FmtLog(LogLevelInfo, "Found 400 in output - PASS")
-	} else if strings.Contains(stderrStr, "200") || strings.Contains(stderrStr, "204") ||
-		strings.Contains(stderrStr, "301") || strings.Contains(stderrStr, "302") ||
-		strings.Contains(stderrStr, "304") {
-		status = 1
-		// Try to find the actual status code
-		for _, code := range []int{200, 204, 301, 302, 304} {
-			if strings.Contains(stderrStr, fmt.Sprintf("%d", code)) {
-				effectiveStatusCode = code
-				break
-			}
-		}
-		if effectiveStatusCode == 0 {
-			effectiveStatusCode = 200
-		}
-		FmtLog(LogLevelInfo, "Found 2xx/3xx in output - PASS")
-	} else {
-		status = 0
-		effectiveStatusCode = 0
-		FmtLog(LogLevelWarn, "No success code found - FAIL")
-	}
 
-	FmtLog(LogLevelInfo, "Request completed: status=%d, effective=%d, latency=%.3fs", status, effectiveStatusCode, latency)
+	result := httpStatusResult(p.Name, p.ExpectedStatus, latency, resp.StatusCode)
+	FmtLog(LogLevelInfo, "Request completed: url=%s, final_url=%s, status_code=%d, status=%d, latency=%.3fs",
+		p.URL, resp.Request.URL, resp.StatusCode, result.Status, latency)
 
-	return NewProbeResult(p.Name, status, latency, effectiveStatusCode, nil), nil
+	return result, nil
 }
 
 // AIHealthCheckProbe is an implementation of ProbeExecutor specifically for the AI health check API.
@@ -105,46 +87,59 @@ type AIHealthCheckProbe struct {
 	StatusCheckProbe // Embed StatusCheckProbe
 }
 
-// NewAIHealthCheckProbe creates and returns a new AIHealthCheckProbe instance.
-func NewAIHealthCheckProbe() *AIHealthCheckProbe {
+// NewAIHealthCheckProbe creates and returns a new AIHealthCheckProbe instance using proxyConfig.
+func NewAIHealthCheckProbe(proxyConfig ProxyConfig) *AIHealthCheckProbe {
 	return &AIHealthCheckProbe{
-		StatusCheckProbe: *NewStatusCheckProbe(aiHealthCheckURL, aiHealthCheckName),
+		StatusCheckProbe: *NewStatusCheckProbe(aiHealthCheckURL, aiHealthCheckName, proxyConfig),
 	}
 }
 
 // createAIProbes creates AI health check probes
-func createAIProbes() []ProbeExecutor {
+func createAIProbes(proxyConfig ProxyConfig) []ProbeExecutor {
 	return []ProbeExecutor{
-		NewAIHealthCheckProbe(),
+		NewAIHealthCheckProbe(proxyConfig),
 	}
 }
 
-// StartAIMonitoring creates AI health check probes and starts periodic monitoring in a dedicated goroutine
-func StartAIMonitoring(apiTimeout, probeInterval time.Duration, currentEnv string) {
-	probes := createAIProbes()
+// StartAIMonitoring creates AI health check probes and starts periodic monitoring in a dedicated goroutine.
+// The goroutine exits once ctx is cancelled, after which the returned channel is closed.
+func StartAIMonitoring(ctx context.Context, apiTimeout, probeInterval time.Duration, proxyConfig ProxyConfig, currentEnv string) <-chan struct{} {
+	probes := createAIProbes(proxyConfig)
 
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		for {
 			var wg sync.WaitGroup
-			executeAIProbes(probes, apiTimeout, currentEnv, &wg)
+			executeAIProbes(ctx, probes, apiTimeout, currentEnv, &wg)
 			wg.Wait()
 
 			FmtLog(LogLevelInfo, "AI health check probes completed, waiting for %v before next run...", probeInterval)
-			time.Sleep(probeInterval)
+			select {
+			case <-ctx.Done():
+				FmtLog(LogLevelInfo, "AI health check monitoring stopped")
+				return
+			case <-time.After(probeInterval):
+			}
 		}
 	}()
+	return done
 }
 
-// executeAIProbes executes all AI health check probes in separate goroutines
-func executeAIProbes(probes []ProbeExecutor, apiTimeout time.Duration, currentEnv string, wg *sync.WaitGroup) {
+// executeAIProbes executes all AI health check probes in separate goroutines.
+// Cancelling parent aborts the requests in flight and drops their results.
+func executeAIProbes(parent context.Context, probes []ProbeExecutor, apiTimeout time.Duration, currentEnv string, wg *sync.WaitGroup) {
 	for _, probe := range probes {
 		wg.Add(1)
 		go func(p ProbeExecutor) {
 			defer wg.Done()
-			ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+			ctx, cancel := context.WithTimeout(parent, apiTimeout)
 			defer cancel()
 
 			result, err := p.Execute(ctx)
+			if parent.Err() != nil {
+				return
+			}
 
 			// Set Prometheus metrics based on probe result
 			if err != nil {
@@ -156,12 +151,15 @@ func executeAIProbes(probes []ProbeExecutor, apiTimeout time.Duration, currentEn
 					FmtLog(LogLevelInfo, "AI health check probe %s succeeded (status=%d), latency=%.3fs",
 						result.APIName, result.StatusCode, result.Latency)
 				} else {
-					FmtLog(LogLevelWarn, "AI health check probe %s failed (status=%d), latency=%.3fs",
-						result.APIName, result.StatusCode, result.Latency)
+					FmtLog(LogLevelWarn, "AI health check probe %s failed (status=%d), latency=%.3fs: %s",
+						result.APIName, result.StatusCode, result.Latency, result.Message)
 				}
 				AIHealthStatusGauge.WithLabelValues(result.APIName, currentEnv).Set(float64(result.Status))
 				AIHealthLatencyGauge.WithLabelValues(result.APIName, currentEnv).Set(result.Latency)
 			}
+			if result.StatusCode > 0 {
+				HTTPStatusCodeGauge.WithLabelValues(result.APIName, currentEnv).Set(float64(result.StatusCode))
+			}
 		}(probe)
 	}
 }
@@ -0,0 +1,111 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestStatusCheckProbe returns a StatusCheckProbe for url that connects directly.
+func newTestStatusCheckProbe(url string) *StatusCheckProbe {
+	return NewStatusCheckProbe(url, "status-test", ProxyConfig{})
+}
+
+func TestStatusCheckProbe_DefaultHealthStatusRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		code       int
+		wantStatus int
+	}{
+		{http.StatusOK, 1},
+		{http.StatusNoContent, 1},
+		{http.StatusNotModified, 1},
+		{http.StatusBadRequest, 1},   // Up and rejecting the request
+		{http.StatusUnauthorized, 1}, // Up and enforcing auth
+		{http.StatusForbidden, 1},
+		{http.StatusNotFound, 0},
+		{http.StatusTooManyRequests, 0},
+		{http.StatusInternalServerError, 0},
+		{http.StatusBadGateway, 0},
+		{http.StatusServiceUnavailable, 0},
+	}
+	for _, tt := range tests {
+		result, err := newTestStatusCheckProbe(server.URL + "/" + strconv.Itoa(tt.code)).Execute(context.Background())
+		if err != nil {
+			t.Fatalf("%d: Execute failed: %v", tt.code, err)
+		}
+		if result.Status != tt.wantStatus || result.StatusCode != tt.code {
+			t.Errorf("%d: got status %d, status code %d, want status %d", tt.code, result.Status, result.StatusCode, tt.wantStatus)
+		}
+	}
+}
+
+func TestStatusCheckProbe_Redirects(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case r.URL.Path == "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case strings.HasPrefix(r.URL.Path, "/hop/"):
+			n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/hop/"))
+			if n == 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			http.Redirect(w, r, "/hop/"+strconv.Itoa(n-1), http.StatusMovedPermanently)
+		}
+	}))
+	defer server.Close()
+
+	// Redirects are followed and the final status code is graded
+	result, err := newTestStatusCheckProbe(server.URL + "/hop/3").Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Status != 0 || result.StatusCode != http.StatusServiceUnavailable || requests.Load() != 4 {
+		t.Fatalf("got status %d, status code %d after %d requests, want 0, 503 after 4",
+			result.Status, result.StatusCode, requests.Load())
+	}
+
+	requests.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err = newTestStatusCheckProbe(server.URL + "/loop").Execute(ctx)
+	if err == nil || !strings.Contains(err.Error(), "stopped after 10 redirects") || result.Status != 0 {
+		t.Fatalf("expected the redirect loop to be cut off, got status %d, err %v", result.Status, err)
+	}
+	if got := requests.Load(); got != aiHealthCheckMaxRedirects+1 {
+		t.Fatalf("expected %d requests, got %d", aiHealthCheckMaxRedirects+1, got)
+	}
+}
+
+func TestStatusCheckProbe_ThroughProxy(t *testing.T) {
+	var proxied atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(r.URL.String() + " " + r.Header.Get("Proxy-Authorization"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	probe := NewStatusCheckProbe("http://ai.example.test/health", "status-test",
+		ProxyConfig{URL: proxy.URL, Username: "monitor", Password: "s3cret"})
+	result, err := probe.Execute(context.Background())
+	if err != nil || result.Status != 1 {
+		t.Fatalf("expected success through the proxy, got status %d, err %v", result.Status, err)
+	}
+	// Basic base64("monitor:s3cret")
+	if got, want := proxied.Load(), "http://ai.example.test/health Basic bW9uaXRvcjpzM2NyZXQ="; got != want {
+		t.Fatalf("proxy saw %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,342 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxAssertionBodyBytes caps how much of a response body is read for assertions.
+const maxAssertionBodyBytes = 1 << 20
+
+// AssertionConfig defines a check on the response body of an HTTP probe.
+//
+//	type: contains | not_contains | regex | json_path
+//	path: JSONPath for json_path assertions, e.g. $.data.items[0].status
+//	operator: exists | not_exists | equals | not_equals | gt | gte | lt | lte (json_path only, default equals)
+//	value: substring, regular expression or expected JSON value
+type AssertionConfig struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Path     string `yaml:"path"`
+	Operator string `yaml:"operator"`
+	Value    string `yaml:"value"`
+}
+
+// AssertionResult is the outcome of a single body assertion.
+type AssertionResult struct {
+	Name    string
+	Passed  bool
+	Message string // Why the assertion failed, empty when it passed
+}
+
+// bodyAssertion is a compiled AssertionConfig.
+type bodyAssertion struct {
+	name     string
+	kind     string
+	path     []jsonPathStep
+	operator string
+	value    string
+	pattern  *regexp.Regexp
+}
+
+// compileAssertions validates and compiles assertion definitions.
+func compileAssertions(cfgs []AssertionConfig) ([]*bodyAssertion, error) {
+	assertions := make([]*bodyAssertion, 0, len(cfgs))
+	seen := make(map[string]bool, len(cfgs))
+	for i, cfg := range cfgs {
+		a, err := compileAssertion(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("assertions[%d]: %w", i, err)
+		}
+		if seen[a.name] {
+			return nil, fmt.Errorf("assertions[%d]: duplicate assertion name %q", i, a.name)
+		}
+		seen[a.name] = true
+		assertions = append(assertions, a)
+	}
+	return assertions, nil
+}
+
+// compileAssertion validates a single assertion and fills in defaults.
+func compileAssertion(cfg AssertionConfig) (*bodyAssertion, error) {
+	a := &bodyAssertion{
+		name:     cfg.Name,
+		kind:     strings.ToLower(cfg.Type),
+		operator: strings.ToLower(cfg.Operator),
+		value:    cfg.Value,
+	}
+
+	switch a.kind {
+	case "contains", "not_contains":
+		if cfg.Value == "" {
+			return nil, fmt.Errorf("%s assertion requires a value", a.kind)
+		}
+		if a.name == "" {
+			a.name = a.kind + ":" + cfg.Value
+		}
+	case "regex":
+		pattern, err := regexp.Compile(cfg.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", cfg.Value, err)
+		}
+		a.pattern = pattern
+		if a.name == "" {
+			a.name = "regex:" + cfg.Value
+		}
+	case "json_path":
+		path, err := parseJSONPath(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		a.path = path
+		if a.operator == "" {
+			a.operator = "equals"
+		}
+		switch a.operator {
+		case "exists", "not_exists", "equals", "not_equals":
+		case "gt", "gte", "lt", "lte":
+			if _, err := strconv.ParseFloat(cfg.Value, 64); err != nil {
+				return nil, fmt.Errorf("operator %s requires a numeric value, got %q", a.operator, cfg.Value)
+			}
+		default:
+			return nil, fmt.Errorf("unknown json_path operator %q", cfg.Operator)
+		}
+		if a.name == "" {
+			a.name = "json_path:" + cfg.Path
+		}
+	default:
+		return nil, fmt.Errorf("unknown assertion type %q (expected contains, not_contains, regex or json_path)", cfg.Type)
+	}
+	return a, nil
+}
+
+// evaluateAssertions runs every assertion against body. The body is decoded as JSON at most once.
+func evaluateAssertions(assertions []*bodyAssertion, body []byte) []AssertionResult {
+	var (
+		doc       interface{}
+		docErr    error
+		docParsed bool
+	)
+	results := make([]AssertionResult, 0, len(assertions))
+	for _, a := range assertions {
+		if a.kind == "json_path" && !docParsed {
+			docErr = json.Unmarshal(body, &doc)
+			docParsed = true
+		}
+		results = append(results, a.evaluate(body, doc, docErr))
+	}
+	return results
+}
+
+// evaluate runs a single assertion.
+func (a *bodyAssertion) evaluate(body []byte, doc interface{}, docErr error) AssertionResult {
+	fail := func(format string, args ...interface{}) AssertionResult {
+		return AssertionResult{Name: a.name, Message: fmt.Sprintf(format, args...)}
+	}
+
+	switch a.kind {
+	case "contains":
+		if !bytes.Contains(body, []byte(a.value)) {
+			return fail("body does not contain %q", a.value)
+		}
+	case "not_contains":
+		if bytes.Contains(body, []byte(a.value)) {
+			return fail("body contains %q", a.value)
+		}
+	case "regex":
+		if !a.pattern.Match(body) {
+			return fail("body does not match /%s/", a.pattern)
+		}
+	case "json_path":
+		if docErr != nil {
+			return fail("body is not valid JSON: %v", docErr)
+		}
+		actual, found := evalJSONPath(doc, a.path)
+		switch a.operator {
+		case "exists":
+			if !found {
+				return fail("%s does not exist", jsonPathString(a.path))
+			}
+		case "not_exists":
+			if found {
+				return fail("%s exists", jsonPathString(a.path))
+			}
+		case "equals", "not_equals":
+			equal := found && jsonValueEquals(actual, a.value)
+			if a.operator == "equals" && !equal {
+				return fail("%s is %s, expected %s", jsonPathString(a.path), formatJSONValue(actual, found), a.value)
+			}
+			if a.operator == "not_equals" && equal {
+				return fail("%s equals %s", jsonPathString(a.path), a.value)
+			}
+		default:
+			n, ok := actual.(float64)
+			if !found || !ok {
+				return fail("%s is %s, expected a number", jsonPathString(a.path), formatJSONValue(actual, found))
+			}
+			want, _ := strconv.ParseFloat(a.value, 64)
+			if !compareNumbers(n, a.operator, want) {
+				return fail("%s is %v, expected %s %s", jsonPathString(a.path), n, a.operator, a.value)
+			}
+		}
+	}
+	return AssertionResult{Name: a.name, Passed: true}
+}
+
+// applyAssertionResults attaches assertion outcomes to result. Any failed assertion
+// turns the verdict into a failure and is added to the result message.
+func applyAssertionResults(result *ProbeResult, assertions []AssertionResult) {
+	result.Assertions = assertions
+	var failed []string
+	for _, a := range assertions {
+		if !a.Passed {
+			failed = append(failed, fmt.Sprintf("assertion %s failed: %s", a.Name, a.Message))
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	result.Status = 0
+	if result.Message != "" {
+		failed = append([]string{result.Message}, failed...)
+	}
+	result.Message = strings.Join(failed, "; ")
+}
+
+// compareNumbers applies a numeric comparison operator.
+func compareNumbers(actual float64, operator string, want float64) bool {
+	switch operator {
+	case "gt":
+		return actual > want
+	case "gte":
+		return actual >= want
+	case "lt":
+		return actual < want
+	case "lte":
+		return actual <= want
+	}
+	return false
+}
+
+// jsonValueEquals compares a decoded JSON value with an expected value from the config.
+// The expected value is parsed as JSON when possible (so 1, true and null compare by type),
+// otherwise it is compared as a plain string.
+func jsonValueEquals(actual interface{}, expected string) bool {
+	var want interface{}
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		want = expected
+	}
+	if reflect.DeepEqual(actual, want) {
+		return true
+	}
+	// Allow status: "1" to match an expected value of 1 written without quotes.
+	if s, ok := actual.(string); ok {
+		return s == expected
+	}
+	return false
+}
+
+// formatJSONValue renders a value for failure messages.
+func formatJSONValue(v interface{}, found bool) string {
+	if !found {
+		return "missing"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// jsonPathStep is one segment of a JSONPath: either an object key or an array index.
+type jsonPathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath parses the subset of JSONPath used by assertions:
+// $.key, $.key.nested, $.items[0], $['key with spaces'] and combinations thereof.
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", path)
+	}
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty key", path)
+			}
+			steps = append(steps, jsonPathStep{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: missing ]", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: bad index [%s]", path, inner)
+			}
+			steps = append(steps, jsonPathStep{index: idx, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", path, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath walks doc along steps. found is false when any segment is missing.
+func evalJSONPath(doc interface{}, steps []jsonPathStep) (value interface{}, found bool) {
+	current := doc
+	for _, step := range steps {
+		if step.isIndex {
+			arr, ok := current.([]interface{})
+			if !ok || step.index >= len(arr) {
+				return nil, false
+			}
+			current = arr[step.index]
+			continue
+		}
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj[step.key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// jsonPathString renders steps back into JSONPath notation for messages.
+func jsonPathString(steps []jsonPathStep) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, s := range steps {
+		if s.isIndex {
+			fmt.Fprintf(&b, "[%d]", s.index)
+		} else {
+			b.WriteString("." + s.key)
+		}
+	}
+	return b.String()
+}
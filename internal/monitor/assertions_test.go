@@ -0,0 +1,67 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPProbe_BodyAssertions(t *testing.T) {
+	body := `{"status": "degraded", "data": {"user_id": 1001, "settings": [{"theme": "dark"}]}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	probe, err := NewProbeFromConfig(ProbeConfig{
+		Name: "health",
+		Type: "http",
+		URL:  server.URL,
+		Assertions: []AssertionConfig{
+			{Name: "status", Type: "json_path", Path: "$.status", Value: "success"},
+			{Name: "theme", Type: "json_path", Path: "$.data.settings[0].theme", Value: "dark"},
+			{Name: "user", Type: "json_path", Path: "$.data.user_id", Operator: "gte", Value: "1000"},
+			{Name: "missing", Type: "json_path", Path: "$.data.profile", Operator: "not_exists"},
+			{Name: "regex", Type: "regex", Value: `"user_id":\s*\d+`},
+			{Name: "no-error", Type: "not_contains", Value: "error"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+
+	result, err := probe.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Status != 0 {
+		t.Fatalf("expected probe to be down because $.status is degraded, got status %d", result.Status)
+	}
+
+	passed := map[string]bool{}
+	for _, a := range result.Assertions {
+		passed[a.Name] = a.Passed
+	}
+	want := map[string]bool{"status": false, "theme": true, "user": true, "missing": true, "regex": true, "no-error": true}
+	for name, ok := range want {
+		if passed[name] != ok {
+			t.Errorf("assertion %s: passed=%v, want %v", name, passed[name], ok)
+		}
+	}
+}
+
+func TestCompileAssertions_Invalid(t *testing.T) {
+	invalid := []AssertionConfig{
+		{Type: "json_path", Path: "status"},
+		{Type: "json_path", Path: "$.a", Operator: "gt", Value: "abc"},
+		{Type: "regex", Value: "("},
+		{Type: "unknown", Value: "x"},
+	}
+	for _, cfg := range invalid {
+		if _, err := compileAssertions([]AssertionConfig{cfg}); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
@@ -229,12 +229,15 @@ const probeRefreshInterval = 30 * time.Minute // Refresh probes every 30 minutes
 const metricErrorValue = -1.0                 // Default value for failed metric fetch, used to mark anomalies
 
 // StartDirectConnectMonitoring creates Direct Connect probes and starts periodic monitoring in a dedicated goroutine
-// This is the only public API needed - it fully encapsulates both probe creation and execution
-func StartDirectConnectMonitoring(awsConfig AWSConfig, apiTimeout, probeInterval time.Duration, currentEnv string) {
+// This is the only public API needed - it fully encapsulates both probe creation and execution.
+// The goroutine exits once ctx is cancelled, after which the returned channel is closed.
+func StartDirectConnectMonitoring(ctx context.Context, awsConfig AWSConfig, apiTimeout, probeInterval time.Duration, currentEnv string) <-chan struct{} {
 	probes := createDxProbes(awsConfig, currentEnv)
 	lastRefreshTime := time.Now()
 
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		for {
 			// Recreate probes every 30 minutes to refresh AWS credentials
 			if time.Since(lastRefreshTime) >= probeRefreshInterval {
@@ -250,22 +253,29 @@ func StartDirectConnectMonitoring(awsConfig AWSConfig, apiTimeout, probeInterval
 			}
 
 			var wg sync.WaitGroup
-			executeDxProbes(probes, apiTimeout, currentEnv, &wg)
+			executeDxProbes(ctx, probes, apiTimeout, currentEnv, &wg)
 			wg.Wait()
 
 			FmtLog(LogLevelInfo, "Direct Connect probes completed, waiting for %v before next run...", probeInterval)
-			time.Sleep(probeInterval)
+			select {
+			case <-ctx.Done():
+				FmtLog(LogLevelInfo, "Direct Connect monitoring stopped")
+				return
+			case <-time.After(probeInterval):
+			}
 		}
 	}()
+	return done
 }
 
-// executeDxProbes executes all Direct Connect probes in separate goroutines and exposes metrics via WithLabelValues
-func executeDxProbes(probes []ProbeExecutor, apiTimeout time.Duration, currentEnv string, wg *sync.WaitGroup) {
+// executeDxProbes executes all Direct Connect probes in separate goroutines and exposes metrics via WithLabelValues.
+// Cancelling parent aborts the CloudWatch calls in flight.
+func executeDxProbes(parent context.Context, probes []ProbeExecutor, apiTimeout time.Duration, currentEnv string, wg *sync.WaitGroup) {
 	for _, probe := range probes {
 		wg.Add(1)
 		go func(p ProbeExecutor) {
 			defer wg.Done()
-			ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+			ctx, cancel := context.WithTimeout(parent, apiTimeout)
 			defer cancel()
 
 			result, err := p.Execute(ctx)
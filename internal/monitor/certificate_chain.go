@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// CertificateInfo describes one certificate of a served chain.
+type CertificateInfo struct {
+	Subject            string
+	Issuer             string
+	SANs               []string // DNS names, IP addresses, email addresses and URIs
+	SerialNumber       string   // Hexadecimal
+	KeyType            string   // RSA, ECDSA, Ed25519 or the Go type name of other keys
+	KeyBits            int
+	SignatureAlgorithm string
+	NotBefore          time.Time
+	NotAfter           time.Time
+	IsCA               bool
+}
+
+// CertificateChain is the chain presented by a server, leaf first, in the order it was sent.
+type CertificateChain []CertificateInfo
+
+// EarliestExpiry returns the certificate of the chain that expires first, so an expiring
+// intermediate is not hidden behind a fresh leaf. It returns nil for an empty chain.
+func (c CertificateChain) EarliestExpiry() *CertificateInfo {
+	var earliest *CertificateInfo
+	for i := range c {
+		if earliest == nil || c[i].NotAfter.Before(earliest.NotAfter) {
+			earliest = &c[i]
+		}
+	}
+	return earliest
+}
+
+// InspectCertificateChain connects to target (URL, host or host:port) and describes every
+// certificate the server presents. The chain is not verified, so expired or untrusted
+// certificates are reported too.
+func InspectCertificateChain(target string, timeout time.Duration) (CertificateChain, error) {
+	chain, _, err := InspectCertificate(context.Background(), target, timeout, CertificateRevocationConfig{})
+	return chain, err
+}
+
+// InspectCertificate is InspectCertificateChain that additionally checks whether the leaf
+// certificate has been revoked, using the methods enabled in revocation. The revocation
+// result is nil when no method is enabled; a failed revocation check is reported in it
+// with an unknown status rather than as an error.
+func InspectCertificate(ctx context.Context, target string, timeout time.Duration, revocation CertificateRevocationConfig) (CertificateChain, *RevocationResult, error) {
+	return inspectCertificate(ctx, target, timeout, revocation, certificateConnection{})
+}
+
+// inspectCertificate implements InspectCertificate for a connection made as described by cc.
+func inspectCertificate(ctx context.Context, target string, timeout time.Duration, revocation CertificateRevocationConfig, cc certificateConnection) (CertificateChain, *RevocationResult, error) {
+	state, err := fetchConnectionState(ctx, target, timeout, cc)
+	if err != nil {
+		return nil, nil, err
+	}
+	chain := make(CertificateChain, 0, len(state.PeerCertificates))
+	for _, cert := range state.PeerCertificates {
+		chain = append(chain, describeCertificate(cert))
+	}
+	var result *RevocationResult
+	if revocation.Enabled() {
+		result = checkRevocation(ctx, state.PeerCertificates, state.OCSPResponse, revocation, timeout, cc.transport)
+	}
+	return chain, result, nil
+}
+
+// certificateConnection describes how the certificate of a target is fetched. The zero value
+// connects directly, sends the target host as SNI and uses http.DefaultTransport for
+// revocation checks.
+type certificateConnection struct {
+	dial      proxyDialFunc     // Opens the TCP connection, direct when nil
+	tlsConfig *tls.Config       // Client certificate and server name, verification is always skipped
+	transport http.RoundTripper // Fetches OCSP responses and CRLs, http.DefaultTransport when nil
+}
+
+// newCertificateConnection builds the connection settings of target from its proxy and tls blocks,
+// so a probe's certificate is fetched the same way the probe reaches its target. OCSP and CRL
+// requests go through the same proxy.
+func newCertificateConnection(target CertificateTargetConfig) (certificateConnection, error) {
+	dial, err := newProxyDialer(target.Proxy, true)
+	if err != nil {
+		return certificateConnection{}, err
+	}
+	tlsConfig, err := newTLSClientConfig(target.TLS)
+	if err != nil {
+		return certificateConnection{}, err
+	}
+	proxy, err := newProxyFunc(target.Proxy)
+	if err != nil {
+		return certificateConnection{}, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.DisableKeepAlives = true // Built for every check, so idle connections would pile up
+	return certificateConnection{dial: dial, tlsConfig: tlsConfig, transport: transport}, nil
+}
+
+// fetchConnectionState completes a TLS handshake with target without verification and
+// returns its state, which holds the certificates presented by the server and any stapled
+// OCSP response. Targets with a STARTTLS scheme are upgraded from plaintext first; timeout
+// covers the whole exchange.
+func fetchConnectionState(ctx context.Context, target string, timeout time.Duration, cc certificateConnection) (tls.ConnectionState, error) {
+	host, port, serverName, starttls, err := normalizeHostPort(target)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	address := net.JoinHostPort(host, port)
+	tlsConfig := &tls.Config{}
+	if cc.tlsConfig != nil {
+		tlsConfig = cc.tlsConfig.Clone()
+	}
+	tlsConfig.InsecureSkipVerify = true
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = serverName
+	}
+	dial := cc.dial
+	if dial == nil {
+		dial = func(ctx context.Context, addr string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	raw, err := dial(ctx, address)
+	if err != nil {
+		return tls.ConnectionState{}, fmt.Errorf("failed to connect: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	raw.SetDeadline(deadline)
+	if starttls != "" {
+		if err := starttlsProtocols[starttls].upgrade(raw); err != nil {
+			raw.Close()
+			return tls.ConnectionState{}, fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+	conn := tls.Client(raw, tlsConfig)
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		if starttls != "" {
+			return tls.ConnectionState{}, fmt.Errorf("tls handshake after %s starttls failed: %w", starttls, err)
+		}
+		return tls.ConnectionState{}, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return tls.ConnectionState{}, fmt.Errorf("no peer certificates found for %s", address)
+	}
+	return state, nil
+}
+
+// describeCertificate extracts the inspected fields of cert.
+func describeCertificate(cert *x509.Certificate) CertificateInfo {
+	info := CertificateInfo{
+		Subject:            cert.Subject.String(),
+		Issuer:             cert.Issuer.String(),
+		SANs:               append([]string(nil), cert.DNSNames...),
+		SerialNumber:       cert.SerialNumber.Text(16),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		NotBefore:          cert.NotBefore,
+		NotAfter:           cert.NotAfter,
+		IsCA:               cert.IsCA,
+	}
+	for _, ip := range cert.IPAddresses {
+		info.SANs = append(info.SANs, ip.String())
+	}
+	info.SANs = append(info.SANs, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		info.SANs = append(info.SANs, uri.String())
+	}
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		info.KeyType, info.KeyBits = "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.KeyType, info.KeyBits = "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		info.KeyType, info.KeyBits = "Ed25519", 256
+	default:
+		info.KeyType = fmt.Sprintf("%T", key)
+	}
+	return info
+}
@@ -0,0 +1,108 @@
+package monitor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInspectCertificateChain(t *testing.T) {
+	now := time.Now()
+	caTemplate := func(cn string, serial int64, notAfter time.Time) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn, Organization: []string{"Test"}},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              notAfter,
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+	}
+	rootKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader) // P-384 so the intermediate is signed with SHA-384
+	root := issueTestCertificate(t, caTemplate("Test Root", 1, now.Add(10*365*24*time.Hour)), nil, rootKey, nil)
+	// The intermediate expires before the leaf, which the leaf TTL alone would not show
+	intermediateKey := newTestKey(t)
+	intermediate := issueTestCertificate(t, caTemplate("Test Intermediate", 2, now.Add(48*time.Hour)), root, intermediateKey, rootKey)
+	leafKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := issueTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(0xabcdef),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(30 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, intermediate, leafKey, intermediateKey)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{leaf.Raw, intermediate.Raw},
+		PrivateKey:  leafKey,
+	}}}
+	server.StartTLS()
+	defer server.Close()
+
+	chain, err := InspectCertificateChain(server.URL, 2*time.Second)
+	if err != nil {
+		t.Fatalf("InspectCertificateChain failed: %v", err)
+	}
+	if len(chain) != 2 {
+		t.Fatalf("expected leaf and intermediate, got %d certificates", len(chain))
+	}
+	want := CertificateInfo{
+		Subject:            "CN=localhost",
+		Issuer:             "CN=Test Intermediate,O=Test",
+		SANs:               []string{"localhost", "127.0.0.1"},
+		SerialNumber:       "abcdef",
+		KeyType:            "RSA",
+		KeyBits:            2048,
+		SignatureAlgorithm: "ECDSA-SHA256",
+		NotBefore:          leaf.NotBefore,
+		NotAfter:           leaf.NotAfter,
+	}
+	if !reflect.DeepEqual(chain[0], want) {
+		t.Errorf("leaf = %+v, want %+v", chain[0], want)
+	}
+	if c := chain[1]; c.Subject != "CN=Test Intermediate,O=Test" || c.Issuer != "CN=Test Root,O=Test" ||
+		c.KeyType != "ECDSA" || c.KeyBits != 256 || c.SignatureAlgorithm != "ECDSA-SHA384" || !c.IsCA {
+		t.Errorf("unexpected intermediate %+v", c)
+	}
+	if earliest := chain.EarliestExpiry(); earliest.Subject != "CN=Test Intermediate,O=Test" {
+		t.Errorf("earliest expiry is %s, want the intermediate", earliest.Subject)
+	}
+
+	exportCertificateChain("chain-test", "test", chain)
+	labels := prometheus.Labels{"api_name": "chain-test", "env": "test"}
+	defer func() {
+		CertificateTTLGauge.Delete(labels)
+		CertificateChainTTLGauge.Delete(labels)
+		CertificateInfoGauge.DeletePartialMatch(labels)
+	}()
+	leafTTL := testutil.ToFloat64(CertificateTTLGauge.With(labels))
+	chainTTL := testutil.ToFloat64(CertificateChainTTLGauge.With(labels))
+	if chainTTL > 48*3600 || leafTTL < 29*24*3600 {
+		t.Errorf("leaf ttl %.0fs, chain ttl %.0fs: expected the chain to expire with the intermediate", leafTTL, chainTTL)
+	}
+	if n := testutil.CollectAndCount(CertificateInfoGauge, "api_certificate_info"); n != 2 {
+		t.Errorf("expected 2 certificate info series, got %d", n)
+	}
+}
@@ -0,0 +1,178 @@
+package monitor
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultCertificateCheckInterval is how often certificates are checked when
+// certificates.interval is not set; expiry dates change rarely.
+const defaultCertificateCheckInterval = time.Hour
+
+// CertificateCheckConfig defines the periodic certificate expiry check. Every probe with an
+// https:// or wss:// url is checked, plus the extra targets listed here.
+type CertificateCheckConfig struct {
+	Interval   string                      `yaml:"interval"`   // Optional, defaults to 1h
+	Timeout    string                      `yaml:"timeout"`    // Optional, falls back to api_timeout
+	Revocation CertificateRevocationConfig `yaml:"revocation"` // Optional OCSP and CRL checks of every leaf
+	Targets    []CertificateTargetConfig   `yaml:"targets"`
+}
+
+// CertificateTargetConfig is a certificate checked without a probe.
+// Services that upgrade to TLS after a plaintext greeting are selected by a scheme such as
+// smtp://, imap://, pop3://, ftp://, ldap:// or postgres://, or by starttls.
+//
+// Targets derived from probes use the probe's proxy and tls settings. Of the tls settings
+// only server_name and the client certificate apply, since the certificate is inspected
+// whether or not it verifies.
+type CertificateTargetConfig struct {
+	Name     string      `yaml:"name"`     // Exported as api_name
+	Target   string      `yaml:"target"`   // URL, host or host:port, port 443 by default
+	StartTLS string      `yaml:"starttls"` // Optional STARTTLS protocol for host or host:port targets
+	Proxy    ProxyConfig `yaml:"proxy"`    // Proxy used to reach the target, direct connection if not set
+	TLS      TLSConfig   `yaml:"tls"`      // Optional SNI override and client certificate
+}
+
+// certificateTargets returns the extra certificate targets followed by the targets of
+// every HTTPS or WSS probe.
+func certificateTargets(mc MonitorConfig) []CertificateTargetConfig {
+	targets := append([]CertificateTargetConfig(nil), mc.Certificates.Targets...)
+	for _, p := range mc.Probes {
+		u, err := url.Parse(p.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		switch u.Scheme {
+		case "https":
+		case "wss":
+			u.Scheme = "https"
+		default:
+			continue
+		}
+		targets = append(targets, CertificateTargetConfig{Name: p.Name, Target: u.String(), Proxy: p.Proxy, TLS: p.TLS})
+	}
+	return targets
+}
+
+// StartCertificateMonitoring inspects every target's certificate chain in a dedicated goroutine,
+// every interval, and exports the leaf and chain expiry and the chain metadata, plus the
+// revocation status of the leaf when revocation enables OCSP or CRL checks.
+// The goroutine exits once ctx is cancelled; the returned channel is closed when it has exited
+// and no check is writing metrics any more.
+func StartCertificateMonitoring(ctx context.Context, targets []CertificateTargetConfig, revocation CertificateRevocationConfig, timeout, interval time.Duration, currentEnv string) <-chan struct{} {
+	done := make(chan struct{})
+	if len(targets) == 0 {
+		FmtLog(LogLevelInfo, "No HTTPS probes or certificate targets configured, certificate monitoring disabled")
+		close(done)
+		return done
+	}
+
+	go func() {
+		defer close(done)
+		for {
+			checkCertificates(ctx, targets, revocation, timeout, currentEnv)
+
+			FmtLog(LogLevelInfo, "Certificate checks completed, waiting for %v before next run...", interval)
+			select {
+			case <-ctx.Done():
+				FmtLog(LogLevelInfo, "Certificate monitoring stopped")
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+	return done
+}
+
+// checkCertificates checks all targets concurrently and exports whether each check succeeded.
+// A target whose certificate cannot be read has its certificate series removed rather than
+// keeping stale values.
+func checkCertificates(ctx context.Context, targets []CertificateTargetConfig, revocation CertificateRevocationConfig, timeout time.Duration, currentEnv string) {
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target CertificateTargetConfig) {
+			defer wg.Done()
+			chain, revoked, err := inspectCertificateTarget(ctx, target, timeout, revocation)
+			if ctx.Err() != nil {
+				return
+			}
+			labels := prometheus.Labels{"api_name": target.Name, "env": currentEnv}
+			CertificateInfoGauge.DeletePartialMatch(labels)
+			CertificateRevocationStatusGauge.DeletePartialMatch(labels)
+			CertificateOCSPAgeGauge.Delete(labels)
+			CertificateOCSPNextUpdateGauge.Delete(labels)
+			if err != nil {
+				FmtLog(LogLevelError, "Certificate check for %s (%s, proxy=%s) failed: %v", target.Name, target.Target, target.Proxy, err)
+				CertificateCheckSuccessGauge.With(labels).Set(0)
+				CertificateTTLGauge.Delete(labels)
+				CertificateChainTTLGauge.Delete(labels)
+				return
+			}
+			CertificateCheckSuccessGauge.With(labels).Set(1)
+			exportCertificateChain(target.Name, currentEnv, chain)
+			if revoked != nil {
+				exportRevocation(target.Name, currentEnv, revoked)
+			}
+		}(target)
+	}
+	wg.Wait()
+}
+
+// inspectCertificateTarget inspects the certificate of target through its proxy, with its
+// STARTTLS protocol and tls settings applied.
+func inspectCertificateTarget(ctx context.Context, target CertificateTargetConfig, timeout time.Duration, revocation CertificateRevocationConfig) (CertificateChain, *RevocationResult, error) {
+	address, err := WithSTARTTLS(target.Target, target.StartTLS)
+	if err != nil {
+		return nil, nil, err
+	}
+	cc, err := newCertificateConnection(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	return inspectCertificate(ctx, address, timeout, revocation, cc)
+}
+
+// exportCertificateChain exports the leaf and chain expiry and one info series per certificate.
+func exportCertificateChain(apiName, currentEnv string, chain CertificateChain) {
+	leafTTL := time.Until(chain[0].NotAfter)
+	earliest := chain.EarliestExpiry()
+	chainTTL := time.Until(earliest.NotAfter)
+	if chainTTL < leafTTL {
+		FmtLog(LogLevelWarn, "Certificate chain of %s expires in %v with %q, before its leaf (%v)",
+			apiName, chainTTL.Round(time.Minute), earliest.Subject, leafTTL.Round(time.Minute))
+	} else {
+		FmtLog(LogLevelInfo, "Certificate of %s expires in %v", apiName, leafTTL.Round(time.Minute))
+	}
+	CertificateTTLGauge.WithLabelValues(apiName, currentEnv).Set(leafTTL.Seconds())
+	CertificateChainTTLGauge.WithLabelValues(apiName, currentEnv).Set(chainTTL.Seconds())
+	for i, cert := range chain {
+		CertificateInfoGauge.WithLabelValues(apiName, currentEnv, strconv.Itoa(i),
+			cert.Subject, cert.Issuer, cert.SerialNumber, cert.KeyType, cert.SignatureAlgorithm).Set(1)
+	}
+}
+
+// exportRevocation exports the revocation status of the leaf and, for OCSP answers, how
+// fresh the response is. Callers delete the previous series first since the method may change.
+func exportRevocation(apiName, currentEnv string, result *RevocationResult) {
+	switch result.Status {
+	case RevocationRevoked:
+		FmtLog(LogLevelError, "Certificate of %s was revoked at %s (%s)", apiName, result.RevokedAt.Format(time.RFC3339), result.Method)
+	case RevocationUnknown:
+		FmtLog(LogLevelWarn, "Revocation status of the certificate of %s is unknown: %v", apiName, result.Err)
+	default:
+		FmtLog(LogLevelInfo, "Certificate of %s is not revoked (%s)", apiName, result.Method)
+	}
+	CertificateRevocationStatusGauge.WithLabelValues(apiName, currentEnv, result.Method).Set(float64(result.Status))
+	if result.Method == RevocationMethodOCSP || result.Method == RevocationMethodOCSPStapled {
+		CertificateOCSPAgeGauge.WithLabelValues(apiName, currentEnv).Set(time.Since(result.ThisUpdate).Seconds())
+		if !result.NextUpdate.IsZero() {
+			CertificateOCSPNextUpdateGauge.WithLabelValues(apiName, currentEnv).Set(time.Until(result.NextUpdate).Seconds())
+		}
+	}
+}
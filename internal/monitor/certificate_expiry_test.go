@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCertificateTargets(t *testing.T) {
+	mc := MonitorConfig{
+		Certificates: CertificateCheckConfig{Targets: []CertificateTargetConfig{{Name: "mail", Target: "mail.example.com:465"}}},
+		Probes: []ProbeConfig{
+			{Name: "api", Type: "http", URL: "https://api.example.com/health",
+				Proxy: ProxyConfig{URL: "http://proxy.example.com:3128"}, TLS: TLSConfig{ServerName: "api.internal"}},
+			{Name: "plain", Type: "http", URL: "http://api.example.com/health"},
+			{Name: "stream", Type: "websocket", URL: "wss://ws.example.com:8443/feed"},
+			{Name: "dns", Type: "dns"},
+		},
+	}
+	want := []CertificateTargetConfig{
+		{Name: "mail", Target: "mail.example.com:465"},
+		{Name: "api", Target: "https://api.example.com/health",
+			Proxy: ProxyConfig{URL: "http://proxy.example.com:3128"}, TLS: TLSConfig{ServerName: "api.internal"}},
+		{Name: "stream", Target: "https://ws.example.com:8443/feed"},
+	}
+	if got := certificateTargets(mc); !reflect.DeepEqual(got, want) {
+		t.Fatalf("certificateTargets = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheckCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	expiry := server.Certificate().NotAfter
+
+	targets := []CertificateTargetConfig{
+		{Name: "cert-ok", Target: server.URL},
+		{Name: "cert-down", Target: "127.0.0.1:1"},
+	}
+	CertificateTTLGauge.WithLabelValues("cert-down", "test").Set(42) // Stale value from an earlier run
+	defer CertificateTTLGauge.Reset()
+
+	checkCertificates(context.Background(), targets, CertificateRevocationConfig{}, 2*time.Second, "test")
+
+	ttl := testutil.ToFloat64(CertificateTTLGauge.WithLabelValues("cert-ok", "test"))
+	if want := time.Until(expiry).Seconds(); ttl <= 0 || ttl > want+1 || ttl < want-60 {
+		t.Errorf("ttl = %.0fs, want about %.0fs", ttl, want)
+	}
+	if CertificateTTLGauge.DeleteLabelValues("cert-down", "test") {
+		t.Error("expected the series of an unreachable target to be removed")
+	}
+	defer CertificateCheckSuccessGauge.Reset()
+	if got := testutil.ToFloat64(CertificateCheckSuccessGauge.WithLabelValues("cert-ok", "test")); got != 1 {
+		t.Errorf("check success of cert-ok = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(CertificateCheckSuccessGauge.WithLabelValues("cert-down", "test")); got != 0 {
+		t.Errorf("check success of cert-down = %v, want 0", got)
+	}
+}
+
+func TestCheckCertificates_ThroughProxyWithServerName(t *testing.T) {
+	var serverName string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		serverName = hello.ServerName
+		return nil, nil
+	}}
+	server.StartTLS()
+	defer server.Close()
+	proxyURL, tunnels := startTestConnectProxy(t, "")
+	defer CertificateTTLGauge.Reset()
+	defer CertificateCheckSuccessGauge.Reset()
+
+	mc := MonitorConfig{Probes: []ProbeConfig{{
+		Name:  "cert-proxied",
+		Type:  "http",
+		URL:   server.URL,
+		Proxy: ProxyConfig{URL: proxyURL},
+		TLS:   TLSConfig{ServerName: "api.internal"},
+	}}}
+	checkCertificates(context.Background(), certificateTargets(mc), CertificateRevocationConfig{}, 2*time.Second, "test")
+
+	if got := testutil.ToFloat64(CertificateCheckSuccessGauge.WithLabelValues("cert-proxied", "test")); got != 1 {
+		t.Fatalf("check success = %v, want 1", got)
+	}
+	if tunnels.Load() != 1 {
+		t.Errorf("expected the check to go through the proxy, got %d tunnels", tunnels.Load())
+	}
+	if serverName != "api.internal" {
+		t.Errorf("SNI = %q, want the probe's tls.server_name", serverName)
+	}
+}
+
+func TestStartCertificateMonitoring_DoneAfterCancel(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	defer CertificateTTLGauge.Reset()
+	defer CertificateCheckSuccessGauge.Reset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := StartCertificateMonitoring(ctx, []CertificateTargetConfig{{Name: "cert-loop", Target: server.URL}},
+		CertificateRevocationConfig{}, 2*time.Second, time.Hour, "test")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("certificate loop did not exit after cancellation")
+	}
+
+	// Once done is closed no check may export series any more
+	CertificateTTLGauge.Reset()
+	time.Sleep(50 * time.Millisecond)
+	if n := testutil.CollectAndCount(CertificateTTLGauge); n != 0 {
+		t.Fatalf("expected no series after the loop exited, got %d", n)
+	}
+
+	select {
+	case <-StartCertificateMonitoring(context.Background(), nil, CertificateRevocationConfig{}, time.Second, time.Hour, "test"):
+	default:
+		t.Fatal("expected the done channel to be closed when there are no targets")
+	}
+}
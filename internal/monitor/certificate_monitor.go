@@ -1,7 +1,7 @@
 package monitor
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
 	"net"
 	"net/url"
@@ -12,31 +12,14 @@ import (
 // GetCertificateExpiry connects to the given target (URL, host or host:port)
 // and returns the certificate NotAfter time (expiry time).
 // Examples of target: "https://example.com", "example.com", "example.com:443".
+// The certificate is neither verified nor checked for revocation; see InspectCertificate.
 func GetCertificateExpiry(target string, timeout time.Duration) (time.Time, error) {
-    host, port, serverName, err := normalizeHostPort(target)
+    state, err := fetchConnectionState(context.Background(), target, timeout, certificateConnection{})
     if err != nil {
-        FmtLog(LogLevelError, "GetCertificateExpiry: normalizeHostPort failed: %v", err)
+        FmtLog(LogLevelError, "GetCertificateExpiry: %s: %v", target, err)
         return time.Time{}, err
     }
 
-    address := net.JoinHostPort(host, port)
-
-    dialer := &net.Dialer{Timeout: timeout}
-    conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
-        InsecureSkipVerify: true,
-        ServerName:         serverName,
-    })
-    if err != nil {
-        FmtLog(LogLevelError, "TLS dial failed for %s: %v", address, err)
-        return time.Time{}, fmt.Errorf("failed to connect: %w", err)
-    }
-    defer conn.Close()
-
-    state := conn.ConnectionState()
-    if len(state.PeerCertificates) == 0 {
-        return time.Time{}, fmt.Errorf("no peer certificates found for %s", address)
-    }
-
     cert := state.PeerCertificates[0]
     return cert.NotAfter, nil
 }
@@ -52,7 +35,9 @@ func GetCertificateTTL(target string, timeout time.Duration) (time.Duration, err
 }
 
 // normalizeHostPort parses target and returns host, port, and serverName for TLS SNI.
-func normalizeHostPort(target string) (host string, port string, serverName string, err error) {
+// Targets with a STARTTLS scheme such as smtp:// or postgres:// also return the protocol
+// used to upgrade the connection, and default to that protocol's port.
+func normalizeHostPort(target string) (host string, port string, serverName string, starttls string, err error) {
     // default TLS port
     port = "443"
 
@@ -60,7 +45,7 @@ func normalizeHostPort(target string) (host string, port string, serverName stri
     if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
         u, perr := url.Parse(target)
         if perr != nil {
-            return "", "", "", perr
+            return "", "", "", "", perr
         }
         host = u.Hostname()
         if p := u.Port(); p != "" {
@@ -72,6 +57,26 @@ func normalizeHostPort(target string) (host string, port string, serverName stri
         return
     }
 
+    // STARTTLS services, e.g. smtp://mail.example.com or postgres://db.example.com:5433
+    if scheme, _, ok := strings.Cut(target, "://"); ok {
+        protocol, known := starttlsProtocols[strings.ToLower(scheme)]
+        if !known {
+            return "", "", "", "", fmt.Errorf("unsupported scheme %q (expected https or one of %s)", scheme, strings.Join(STARTTLSProtocols(), ", "))
+        }
+        u, perr := url.Parse(target)
+        if perr != nil {
+            return "", "", "", "", perr
+        }
+        host = u.Hostname()
+        port = protocol.defaultPort
+        if p := u.Port(); p != "" {
+            port = p
+        }
+        serverName = host
+        starttls = protocol.name
+        return
+    }
+
     // If target contains colon, assume host:port
     if strings.Contains(target, ":") {
         h, p, perr := net.SplitHostPort(target)
@@ -1,9 +1,13 @@
 package monitor
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath" // Import filepath for path manipulation
+	"time"
 
 	"github.com/goccy/go-yaml"
 )
@@ -11,39 +15,99 @@ import (
 // DirectConnectConfig defines configuration for AWS Direct Connect monitoring
 type DirectConnectConfig struct {
 	ConnectionIDs          []string `yaml:"connection_ids"`
-	CollectInterval        string   `yaml:"collect_interval"`
+	CollectInterval        string   `yaml:"collect_interval"`         // Optional, falls back to api_probe_interval
+	CollectTimeout         string   `yaml:"collect_timeout"`          // Optional, falls back to api_timeout
 	MetricsLookbackMinutes int      `yaml:"metrics_lookback_minutes"` // CloudWatch metrics lookback time, default 10 minutes
 }
 
 // AWSConfig defines AWS related configuration
 type AWSConfig struct {
-	Region         string               `yaml:"region"`
-	AccessKey      string               `yaml:"access_key"`
-	SecretKey      string               `yaml:"secret_key"`
-	DirectConnect  DirectConnectConfig  `yaml:"direct_connect"`
+	Region        string              `yaml:"region"`
+	AccessKey     string              `yaml:"access_key" secret:"true"`
+	SecretKey     string              `yaml:"secret_key" secret:"true"`
+	DirectConnect DirectConnectConfig `yaml:"direct_connect"`
+}
+
+// AIHealthCheckConfig defines the schedule and connection of the built-in AI health check.
+type AIHealthCheckConfig struct {
+	Interval string      `yaml:"interval"` // Optional, falls back to api_probe_interval
+	Timeout  string      `yaml:"timeout"`  // Optional, falls back to api_timeout
+	Proxy    ProxyConfig `yaml:"proxy"`    // Optional, direct connection if not set
+}
+
+// ProbeConfig defines a single declarative probe under monitor_config.probes.
+// Type selects the ProbeExecutor implementation from the probe type registry.
+type ProbeConfig struct {
+	Name     string            `yaml:"name"`
+	Type     string            `yaml:"type"`
+	URL      string            `yaml:"url"`
+	Method   string            `yaml:"method"`                // HTTP method, defaults to GET
+	Headers  map[string]string `yaml:"headers" secret:"keys"` // Request headers
+	Body     string            `yaml:"body" secret:"true"`    // Inline request body
+	BodyFile string            `yaml:"body_file"`             // Request body read from a file, exclusive with body
+	Query    map[string]string `yaml:"query" secret:"keys"`   // Query parameters added to the url
+	Auth     AuthConfig        `yaml:"auth"`
+	Timeout  string            `yaml:"timeout"`  // Optional, falls back to api_timeout
+	Interval string            `yaml:"interval"` // Optional, falls back to api_probe_interval
+	Labels   map[string]string `yaml:"labels"`
+	// ExpectedStatus lists accepted HTTP status codes: "200", "2xx", "200-299" or "!5xx" to exclude.
+	// Defaults to 2xx and 3xx.
+	ExpectedStatus []string `yaml:"expected_status"`
+	// Assertions are checked against the response body; any failure marks the probe down.
+	Assertions []AssertionConfig `yaml:"assertions"`
+	// Proxy used to reach the target, direct connection if not set.
+	Proxy ProxyConfig `yaml:"proxy"`
+	// TLS settings of https and wss targets; the server certificate is verified by default.
+	// tls.enabled is implied by the url scheme, except for grpc probes where it turns TLS on.
+	TLS TLSConfig `yaml:"tls"`
+	// DNS defines the query of "dns" probes.
+	DNS DNSConfig `yaml:"dns"`
+	// TCP defines the connection of "tcp" probes.
+	TCP TCPConfig `yaml:"tcp"`
+	// GRPC defines the health check of "grpc" probes.
+	GRPC GRPCConfig `yaml:"grpc"`
+	// WebSocket defines the message exchange of "websocket" probes.
+	WebSocket WebSocketConfig `yaml:"websocket"`
+	// SSE defines the event awaited by "sse" probes.
+	SSE SSEConfig `yaml:"sse"`
+	// Steps are the requests of "transaction" probes, run in order.
+	Steps []TransactionStepConfig `yaml:"steps"`
+	// Exec defines the check command of "exec" probes.
+	Exec ExecConfig `yaml:"exec"`
+	// LLM defines the chat completion of "llm" probes.
+	LLM LLMConfig `yaml:"llm"`
 }
 
 // MonitorConfig defines the general configuration for the monitoring service.
 type MonitorConfig struct {
-	APITimeout       string `yaml:"api_timeout"`
-	APIProbeInterval string `yaml:"api_probe_interval"`
-	CurrentEnv       string `yaml:"current_env"`
-	MetricsPort      string `yaml:"metrics_port"`
-	AWS              AWSConfig `yaml:"aws"`
+	APITimeout       string                 `yaml:"api_timeout"`
+	APIProbeInterval string                 `yaml:"api_probe_interval"`
+	CurrentEnv       string                 `yaml:"current_env"`
+	MetricsPort      string                 `yaml:"metrics_port"`
+	AWS              AWSConfig              `yaml:"aws"`
+	Probes           []ProbeConfig          `yaml:"probes"`
+	AIHealthCheck    AIHealthCheckConfig    `yaml:"ai_health_check"`
+	Certificates     CertificateCheckConfig `yaml:"certificates"`
+	// Environments holds per-environment overrides that are deep-merged over this config
+	// for the selected environment. It is always empty after loading.
+	Environments map[string]MonitorConfig `yaml:"environments,omitempty"`
 }
 
 // YAMLConfig defines the structure of the YAML configuration file.
 type YAMLConfig struct {
 	MonitorConfig MonitorConfig `yaml:"monitor_config"`
+	// references holds the paths of string fields resolved from ${ENV} or file: references,
+	// which are masked by RedactedConfig.
+	references map[string]bool `yaml:"-"`
 }
 
-// LoadYAMLConfig loads API configuration from a YAML file.
-func LoadYAMLConfig(filePath string) (*YAMLConfig, error) {
+// resolveConfigPath turns filePath into the absolute path of the YAML file to read.
+func resolveConfigPath(filePath string) (string, error) {
 	// Parse file path: if it's a relative path, look for it in the current working directory.
 	if !filepath.IsAbs(filePath) { // Use filepath.IsAbs to check for absolute path.
 		cwd, err := os.Getwd()
 		if err != nil {
-			return nil, fmt.Errorf("failed to get current working directory: %w", err)
+			return "", fmt.Errorf("failed to get current working directory: %w", err)
 		}
 		filePath = filepath.Join(cwd, filePath) // Use filepath.Join to safely concatenate paths.
 	}
@@ -52,17 +116,149 @@ func LoadYAMLConfig(filePath string) (*YAMLConfig, error) {
 	if filepath.Ext(filePath) == "" {
 		filePath += ".yaml"
 	}
+	return filePath, nil
+}
+
+// LoadYAMLConfig loads API configuration from a YAML file for the environment named by current_env.
+func LoadYAMLConfig(filePath string) (*YAMLConfig, error) {
+	return LoadYAMLConfigForEnv(filePath, "")
+}
+
+// LoadYAMLConfigForEnv loads API configuration from a YAML file and deep-merges the
+// environments.<env> section and the application-<env>.yaml overlay file over it.
+// An empty env selects the environment named by current_env.
+func LoadYAMLConfigForEnv(filePath, env string) (*YAMLConfig, error) {
+	filePath, err := resolveConfigPath(filePath)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := os.ReadFile(filePath)
+	merged, err := loadMergedConfig(filePath, env)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read YAML file %s: %w", filePath, err)
+		return nil, err
+	}
+	if merged.overlayPath != "" {
+		FmtLog(LogLevelInfo, "Applied configuration overlay %s for environment %s", merged.overlayPath, merged.env)
 	}
 
 	var config YAMLConfig
-	err = yaml.Unmarshal(data, &config)
+	err = yaml.Unmarshal(merged.data, &config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal YAML data from %s: %w", filePath, err)
 	}
 
+	// Resolve ${ENV_VAR}, ${ENV_VAR:-default} and file:/path references in string fields.
+	if errs := resolveConfigReferences(&config); len(errs) > 0 {
+		joined := make([]error, len(errs))
+		for i, e := range errs {
+			joined[i] = e
+		}
+		return nil, fmt.Errorf("failed to resolve references in %s: %w", filePath, errors.Join(joined...))
+	}
+
 	return &config, nil
-}
\ No newline at end of file
+}
+
+// Settings is the parsed form of MonitorConfig that the running monitor works from.
+type Settings struct {
+	APITimeout        time.Duration
+	APIProbeInterval  time.Duration
+	DXCollectTimeout  time.Duration               // Direct Connect collector timeout, defaults to APITimeout
+	DXCollectInterval time.Duration               // Direct Connect collector interval, defaults to APIProbeInterval
+	AITimeout         time.Duration               // AI health check timeout, defaults to APITimeout
+	AIInterval        time.Duration               // AI health check interval, defaults to APIProbeInterval
+	AIProxy           ProxyConfig                 // AI health check proxy, direct connection by default
+	CertTimeout       time.Duration               // Certificate check timeout, defaults to APITimeout
+	CertInterval      time.Duration               // Certificate check interval, defaults to 1h
+	CertTargets       []CertificateTargetConfig   // Extra targets and HTTPS probe targets
+	CertRevocation    CertificateRevocationConfig // OCSP and CRL checks of every certificate target
+	CurrentEnv        string
+	MetricsPort       string
+	AWS               AWSConfig
+	Probes            []ProbeConfig
+	Hash              string      // SHA-256 of the effective configuration
+	Config            *YAMLConfig // Effective configuration the settings were parsed from
+}
+
+// NewSettings parses the durations in cfg and applies the command-line environment override.
+func NewSettings(cfg *YAMLConfig, envOverride string) (*Settings, error) {
+	apiTimeout, err := time.ParseDuration(cfg.MonitorConfig.APITimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing api_timeout: %w", err)
+	}
+	apiProbeInterval, err := time.ParseDuration(cfg.MonitorConfig.APIProbeInterval)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing api_probe_interval: %w", err)
+	}
+
+	// Direct Connect, AI health check and certificate check timeouts fall back to the global defaults
+	dx := cfg.MonitorConfig.AWS.DirectConnect
+	dxCollectTimeout, err := resolveProbeDuration(dx.CollectTimeout, apiTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing aws.direct_connect.collect_timeout: %w", err)
+	}
+	dxCollectInterval, err := resolveProbeDuration(dx.CollectInterval, apiProbeInterval)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing aws.direct_connect.collect_interval: %w", err)
+	}
+	ai := cfg.MonitorConfig.AIHealthCheck
+	aiTimeout, err := resolveProbeDuration(ai.Timeout, apiTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ai_health_check.timeout: %w", err)
+	}
+	aiInterval, err := resolveProbeDuration(ai.Interval, apiProbeInterval)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ai_health_check.interval: %w", err)
+	}
+	certs := cfg.MonitorConfig.Certificates
+	certTimeout, err := resolveProbeDuration(certs.Timeout, apiTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificates.timeout: %w", err)
+	}
+	certInterval, err := resolveProbeDuration(certs.Interval, defaultCertificateCheckInterval)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificates.interval: %w", err)
+	}
+
+	currentEnv := cfg.MonitorConfig.CurrentEnv
+	// If an environment is provided via command line, it overrides the one in the config file
+	if envOverride != "" {
+		currentEnv = envOverride
+	}
+
+	hash, err := ConfigHash(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Settings{
+		APITimeout:        apiTimeout,
+		APIProbeInterval:  apiProbeInterval,
+		DXCollectTimeout:  dxCollectTimeout,
+		DXCollectInterval: dxCollectInterval,
+		AITimeout:         aiTimeout,
+		AIInterval:        aiInterval,
+		AIProxy:           ai.Proxy,
+		CertTimeout:       certTimeout,
+		CertInterval:      certInterval,
+		CertTargets:       certificateTargets(cfg.MonitorConfig),
+		CertRevocation:    certs.Revocation,
+		CurrentEnv:        currentEnv,
+		MetricsPort:       cfg.MonitorConfig.MetricsPort,
+		AWS:               cfg.MonitorConfig.AWS,
+		Probes:            cfg.MonitorConfig.Probes,
+		Hash:              hash,
+		Config:            cfg,
+	}, nil
+}
+
+// ConfigHash returns a hex SHA-256 of the effective configuration.
+// It is computed over the decoded struct, so comments and formatting do not affect it.
+func ConfigHash(cfg *YAMLConfig) (string, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal configuration for hashing: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
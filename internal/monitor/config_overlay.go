@@ -0,0 +1,164 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// configOverlayPath returns the per-environment overlay file for basePath,
+// e.g. configs/application-prod.yaml for configs/application.yaml and env "prod".
+func configOverlayPath(basePath, env string) string {
+	ext := filepath.Ext(basePath)
+	return strings.TrimSuffix(basePath, ext) + "-" + env + ext
+}
+
+// configOverlayGlob matches every overlay file that may belong to basePath.
+func configOverlayGlob(basePath string) string {
+	ext := filepath.Ext(basePath)
+	return strings.TrimSuffix(basePath, ext) + "-*" + ext
+}
+
+// mergedConfig is the result of applying the environment overlays to the base configuration.
+type mergedConfig struct {
+	data        []byte // merged YAML document
+	env         string // selected environment
+	overlayPath string // overlay file that was applied, empty if none exists
+}
+
+// loadMergedConfig reads the base configuration at basePath and deep-merges the selected
+// environment on top of it, in this order:
+//
+//  1. monitor_config.environments.<env> from the base file
+//  2. the overlay file <base>-<env>.yaml next to the base file, if present
+//
+// The environment is envOverride when set, otherwise monitor_config.current_env.
+func loadMergedConfig(basePath, envOverride string) (*mergedConfig, error) {
+	base, err := readYAMLMap(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	monitorCfg, _ := base["monitor_config"].(map[string]interface{})
+	if monitorCfg == nil {
+		monitorCfg = map[string]interface{}{}
+	}
+
+	env := envOverride
+	if env == "" {
+		if current, ok := monitorCfg["current_env"].(string); ok {
+			if env, err = expandConfigString(current); err != nil {
+				return nil, fmt.Errorf("failed to resolve current_env in %s: %w", basePath, err)
+			}
+		}
+	}
+
+	environments, _ := monitorCfg["environments"].(map[string]interface{})
+	delete(monitorCfg, "environments")
+	if env != "" {
+		if section, ok := environments[env].(map[string]interface{}); ok {
+			monitorCfg = mergeYAMLValues(monitorCfg, section).(map[string]interface{})
+		}
+	}
+	base["monitor_config"] = monitorCfg
+
+	result := &mergedConfig{env: env}
+	if env != "" {
+		overlayPath := configOverlayPath(basePath, env)
+		overlay, err := readYAMLMap(overlayPath)
+		switch {
+		case err == nil:
+			base = mergeYAMLValues(base, overlay).(map[string]interface{})
+			result.overlayPath = overlayPath
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		}
+	}
+
+	// Record the environment that was actually selected.
+	if env != "" {
+		if mc, ok := base["monitor_config"].(map[string]interface{}); ok {
+			mc["current_env"] = env
+		}
+	}
+
+	result.data, err = yaml.Marshal(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render merged configuration: %w", err)
+	}
+	return result, nil
+}
+
+// readYAMLMap decodes a YAML file into a generic map so overlays can be merged key by key.
+func readYAMLMap(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML file %s: %w", path, err)
+	}
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML data from %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// mergeYAMLValues deep-merges overlay on top of base.
+// Maps are merged recursively, lists of maps that all carry a "name" key (such as probes)
+// are merged entry by entry by name, and anything else is replaced by the overlay value.
+func mergeYAMLValues(base, overlay interface{}) interface{} {
+	switch o := overlay.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			return o
+		}
+		merged := make(map[string]interface{}, len(b)+len(o))
+		for k, v := range b {
+			merged[k] = v
+		}
+		for k, v := range o {
+			merged[k] = mergeYAMLValues(b[k], v)
+		}
+		return merged
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok || !namedList(b) || !namedList(o) {
+			return o
+		}
+		merged := append([]interface{}{}, b...)
+		index := make(map[string]int, len(b))
+		for i, item := range b {
+			index[item.(map[string]interface{})["name"].(string)] = i
+		}
+		for _, item := range o {
+			name := item.(map[string]interface{})["name"].(string)
+			if i, ok := index[name]; ok {
+				merged[i] = mergeYAMLValues(merged[i], item)
+				continue
+			}
+			index[name] = len(merged)
+			merged = append(merged, item)
+		}
+		return merged
+	default:
+		return overlay
+	}
+}
+
+// namedList reports whether every element of list is a map with a string "name" key.
+func namedList(list []interface{}) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadYAMLConfigForEnv_MergesOverlays(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "application.yaml")
+	writeFile(t, base, `monitor_config:
+  api_timeout: "10s"
+  api_probe_interval: "60s"
+  current_env: "dev"
+  metrics_port: ":7999"
+  probes:
+    - name: "api"
+      type: "http"
+      url: "https://dev.example.com"
+    - name: "static"
+      type: "http"
+      url: "https://static.example.com"
+  environments:
+    prod:
+      api_timeout: "5s"
+      probes:
+        - name: "api"
+          url: "https://prod.example.com"
+`)
+	writeFile(t, filepath.Join(dir, "application-prod.yaml"), `monitor_config:
+  aws:
+    region: "us-east-1"
+  probes:
+    - name: "extra"
+      type: "http"
+      url: "https://extra.example.com"
+`)
+
+	cfg, err := LoadYAMLConfigForEnv(base, "prod")
+	if err != nil {
+		t.Fatalf("LoadYAMLConfigForEnv failed: %v", err)
+	}
+	mc := cfg.MonitorConfig
+	if mc.CurrentEnv != "prod" || mc.APITimeout != "5s" || mc.APIProbeInterval != "60s" || mc.AWS.Region != "us-east-1" {
+		t.Fatalf("unexpected merged settings: %+v", mc)
+	}
+	if len(mc.Probes) != 3 {
+		t.Fatalf("expected 3 probes, got %+v", mc.Probes)
+	}
+	if p := mc.Probes[0]; p.Name != "api" || p.Type != "http" || p.URL != "https://prod.example.com" {
+		t.Fatalf("probe not merged by name: %+v", p)
+	}
+	if mc.Probes[2].Name != "extra" {
+		t.Fatalf("overlay probe not appended: %+v", mc.Probes)
+	}
+	if len(mc.Environments) != 0 {
+		t.Fatalf("environments should be consumed by the merge, got %+v", mc.Environments)
+	}
+
+	dev, err := LoadYAMLConfig(base)
+	if err != nil {
+		t.Fatalf("LoadYAMLConfig failed: %v", err)
+	}
+	if dev.MonitorConfig.APITimeout != "10s" || dev.MonitorConfig.Probes[0].URL != "https://dev.example.com" {
+		t.Fatalf("prod overrides leaked into dev: %+v", dev.MonitorConfig)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
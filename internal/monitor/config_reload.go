@@ -0,0 +1,143 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// configWatchInterval is how often the configuration file is checked for changes.
+const configWatchInterval = 5 * time.Second
+
+// configReloader re-reads the configuration on SIGHUP or when the file contents change,
+// and hands the result to the probe supervisor.
+type configReloader struct {
+	mu           sync.Mutex
+	configPath   string
+	envOverride  string
+	supervisor   *probeSupervisor
+	lastFileHash string
+}
+
+// newConfigReloader creates a reloader for configPath and records the current file contents
+// so the first poll does not trigger a spurious reload.
+func newConfigReloader(configPath, envOverride string, supervisor *probeSupervisor) *configReloader {
+	r := &configReloader{
+		configPath:  configPath,
+		envOverride: envOverride,
+		supervisor:  supervisor,
+	}
+	if hash, err := r.fileHash(); err == nil {
+		r.lastFileHash = hash
+	}
+	return r
+}
+
+// watch blocks forever, reloading on SIGHUP and on file content changes.
+func (r *configReloader) watch() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	ticker := time.NewTicker(configWatchInterval)
+	defer ticker.Stop()
+
+	FmtLog(LogLevelInfo, "Watching %s for changes (send SIGHUP to force a reload)", r.configPath)
+	for {
+		select {
+		case <-sighup:
+			r.reload("SIGHUP")
+		case <-ticker.C:
+			hash, err := r.fileHash()
+			if err != nil {
+				FmtLog(LogLevelWarn, "Unable to check configuration file for changes: %v", err)
+				continue
+			}
+			if hash != r.lastFileHash {
+				r.reload("file change")
+			}
+		}
+	}
+}
+
+// reload loads and applies the configuration, recording the outcome as metrics.
+// On failure the currently running configuration is kept.
+func (r *configReloader) reload(trigger string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	FmtLog(LogLevelInfo, "Reloading configuration from %s (trigger: %s)", r.configPath, trigger)
+
+	// Recorded whatever the outcome, so the next poll neither repeats a SIGHUP reload nor
+	// retries a broken file until it changes again
+	if hash, err := r.fileHash(); err == nil {
+		r.lastFileHash = hash
+	}
+
+	settings, err := r.load()
+	if err == nil {
+		err = r.supervisor.apply(settings, true)
+	}
+	if err != nil {
+		FmtLog(LogLevelError, "Configuration reload failed, keeping previous configuration: %v", err)
+		recordConfigReload(false, "")
+		return err
+	}
+
+	FmtLog(LogLevelInfo, "Configuration reloaded successfully (hash=%s)", settings.Hash)
+	recordConfigReload(true, settings.Hash)
+	return nil
+}
+
+// load reads the configuration file and parses it into Settings.
+func (r *configReloader) load() (*Settings, error) {
+	cfg, err := LoadYAMLConfigForEnv(r.configPath, r.envOverride)
+	if err != nil {
+		return nil, err
+	}
+	return NewSettings(cfg, r.envOverride)
+}
+
+// fileHash returns the SHA-256 of the raw contents of the configuration file and
+// all of its per-environment overlay files.
+func (r *configReloader) fileHash() (string, error) {
+	path, err := resolveConfigPath(r.configPath)
+	if err != nil {
+		return "", err
+	}
+	overlays, err := filepath.Glob(configOverlayGlob(path))
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, p := range append([]string{path}, overlays...) {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", p, len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordConfigReload exports the outcome of a configuration load.
+// hash is only used on success, when it replaces the previously exported hash.
+func recordConfigReload(success bool, hash string) {
+	if !success {
+		ConfigReloadSuccessGauge.Set(0)
+		ConfigReloadsTotal.WithLabelValues("failure").Inc()
+		return
+	}
+	ConfigReloadSuccessGauge.Set(1)
+	ConfigReloadsTotal.WithLabelValues("success").Inc()
+	ConfigReloadTimestampGauge.SetToCurrentTime()
+	ConfigHashGauge.Reset()
+	ConfigHashGauge.WithLabelValues(hash).Set(1)
+}
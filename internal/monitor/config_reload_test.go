@@ -0,0 +1,79 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testReloadConfig = `monitor_config:
+  api_timeout: "1s"
+  api_probe_interval: "1h"
+  current_env: "test"
+  metrics_port: ":7999"
+`
+
+func TestConfigReloader_ReloadRecordsOutcome(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "application.yaml")
+	if err := os.WriteFile(configFile, []byte(testReloadConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := newProbeSupervisor()
+	defer stopTestSupervisor(s)
+	r := newConfigReloader(configFile, "", s)
+
+	successes := testutil.ToFloat64(ConfigReloadsTotal.WithLabelValues("success"))
+	if err := r.reload("test"); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	hash := s.settings.Hash
+	if testutil.ToFloat64(ConfigReloadSuccessGauge) != 1 || testutil.ToFloat64(ConfigHashGauge.WithLabelValues(hash)) != 1 {
+		t.Fatal("successful reload not recorded")
+	}
+	if got := testutil.ToFloat64(ConfigReloadsTotal.WithLabelValues("success")); got != successes+1 {
+		t.Fatalf("success count = %v, want %v", got, successes+1)
+	}
+
+	// A broken file is rejected and the previous configuration and hash stay active
+	failures := testutil.ToFloat64(ConfigReloadsTotal.WithLabelValues("failure"))
+	if err := os.WriteFile(configFile, []byte("monitor_config:\n  api_timeout: \"soon\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reload("test"); err == nil {
+		t.Fatal("expected reload of an invalid configuration to fail")
+	}
+	if testutil.ToFloat64(ConfigReloadSuccessGauge) != 0 || testutil.CollectAndCount(ConfigHashGauge) != 1 ||
+		testutil.ToFloat64(ConfigHashGauge.WithLabelValues(hash)) != 1 || s.settings.Hash != hash {
+		t.Fatal("failed reload replaced the active configuration")
+	}
+	if got := testutil.ToFloat64(ConfigReloadsTotal.WithLabelValues("failure")); got != failures+1 {
+		t.Fatalf("failure count = %v, want %v", got, failures+1)
+	}
+}
+
+func TestConfigReloader_ReloadRefreshesFileHash(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "application.yaml")
+	if err := os.WriteFile(configFile, []byte(testReloadConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := newProbeSupervisor()
+	defer stopTestSupervisor(s)
+	r := newConfigReloader(configFile, "", s)
+
+	// An edit followed by SIGHUP must not be reloaded a second time by the next poll
+	if err := os.WriteFile(configFile, []byte(testReloadConfig+"  ai_health_check:\n    interval: \"2h\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reload("SIGHUP"); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	hash, err := r.fileHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.lastFileHash != hash {
+		t.Fatal("reload did not record the hash of the file it loaded")
+	}
+}
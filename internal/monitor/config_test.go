@@ -0,0 +1,33 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSettings_ScheduleFallbacks(t *testing.T) {
+	cfg := &YAMLConfig{MonitorConfig: MonitorConfig{
+		APITimeout:       "10s",
+		APIProbeInterval: "60s",
+		AWS: AWSConfig{DirectConnect: DirectConnectConfig{
+			CollectInterval: "5m",
+		}},
+		AIHealthCheck: AIHealthCheckConfig{Timeout: "3s"},
+	}}
+
+	settings, err := NewSettings(cfg, "")
+	if err != nil {
+		t.Fatalf("NewSettings failed: %v", err)
+	}
+	if settings.DXCollectInterval != 5*time.Minute || settings.DXCollectTimeout != 10*time.Second {
+		t.Fatalf("unexpected Direct Connect schedule: interval=%s timeout=%s", settings.DXCollectInterval, settings.DXCollectTimeout)
+	}
+	if settings.AIInterval != time.Minute || settings.AITimeout != 3*time.Second {
+		t.Fatalf("unexpected AI health check schedule: interval=%s timeout=%s", settings.AIInterval, settings.AITimeout)
+	}
+
+	cfg.MonitorConfig.AWS.DirectConnect.CollectTimeout = "soon"
+	if _, err := NewSettings(cfg, ""); err == nil {
+		t.Fatal("expected an error for an invalid collect_timeout")
+	}
+}
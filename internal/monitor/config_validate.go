@@ -0,0 +1,454 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+var (
+	// awsRegionPattern matches region names such as "us-east-1", "cn-northwest-1" or "us-gov-west-1".
+	awsRegionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	// dxConnectionIDPattern matches AWS Direct Connect connection IDs such as "dxcon-fg5678gh".
+	dxConnectionIDPattern = regexp.MustCompile(`^dxcon-[a-z0-9]{8}$`)
+)
+
+// ConfigError describes a single problem found while validating a configuration file.
+type ConfigError struct {
+	File    string // File the position refers to, empty if unknown
+	Path    string // YAML path of the offending value, e.g. $.monitor_config.api_timeout
+	Line    int    // 1-based line, 0 if unknown
+	Column  int    // 1-based column, 0 if unknown
+	Message string
+	Warning bool // Worth reporting, but the configuration is still valid
+}
+
+// Error implements the error interface.
+func (e ConfigError) Error() string {
+	prefix := ""
+	if e.File != "" {
+		prefix = e.File + ":"
+	}
+	message := e.Message
+	if e.Warning {
+		message = "warning: " + message
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s%d:%d: %s: %s", prefix, e.Line, e.Column, e.Path, message)
+	}
+	return fmt.Sprintf("%s%s: %s", prefix, e.Path, message)
+}
+
+// ValidateConfigFile strictly decodes the YAML file at filePath, together with the overlay
+// for env (see LoadYAMLConfigForEnv), and returns every problem found: syntax errors, unknown
+// keys, type mismatches and invalid values of the merged configuration. An empty env selects
+// current_env. Problems that do not make the configuration invalid, such as an exec command
+// missing on this host, are marked as warnings. The returned error is only set when the base
+// file itself cannot be read.
+func ValidateConfigFile(filePath, env string) ([]ConfigError, error) {
+	filePath, err := resolveConfigPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read YAML file %s: %w", filePath, err)
+	}
+
+	baseFile, errs, ok := validateDocument(data, filePath)
+	if !ok {
+		return errs, nil
+	}
+
+	merged, err := loadMergedConfig(filePath, env)
+	if err != nil {
+		return append(errs, ConfigError{File: filePath, Path: "$", Message: err.Error()}), nil
+	}
+
+	var overlayFile *ast.File
+	if merged.overlayPath != "" {
+		overlayData, err := os.ReadFile(merged.overlayPath)
+		if err != nil {
+			return append(errs, ConfigError{File: merged.overlayPath, Path: "$", Message: err.Error()}), nil
+		}
+		var overlayErrs []ConfigError
+		overlayFile, overlayErrs, ok = validateDocument(overlayData, merged.overlayPath)
+		errs = append(errs, overlayErrs...)
+		if !ok {
+			return errs, nil
+		}
+	}
+
+	var cfg YAMLConfig
+	if err := yaml.Unmarshal(merged.data, &cfg); err != nil {
+		return append(errs, ConfigError{File: filePath, Path: "$", Message: err.Error()}), nil
+	}
+
+	for _, e := range validateSemantics(&cfg) {
+		// Values overridden by the overlay file or the environments section are reported
+		// where they were defined; everything else points into the base file.
+		envPath := strings.Replace(e.Path, "$.monitor_config", "$.monitor_config.environments."+merged.env, 1)
+		if line, col, found := exactPositionOf(overlayFile, e.Path); found {
+			e.File, e.Line, e.Column = merged.overlayPath, line, col
+		} else if line, col, found := exactPositionOf(baseFile, envPath); found && merged.env != "" {
+			e.File, e.Line, e.Column = filePath, line, col
+		} else {
+			e.File = filePath
+			e.Line, e.Column = positionOf(baseFile, e.Path)
+		}
+		errs = append(errs, e)
+	}
+
+	sortConfigErrors(errs)
+	return errs, nil
+}
+
+// ValidateConfigData validates a single YAML document that is already in memory,
+// without applying environment overlays.
+func ValidateConfigData(data []byte) []ConfigError {
+	file, errs, ok := validateDocument(data, "")
+	if !ok {
+		return errs
+	}
+
+	var cfg YAMLConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return append(errs, configErrorFromYAML("", "$", err))
+	}
+	for _, e := range validateSemantics(&cfg) {
+		e.Line, e.Column = positionOf(file, e.Path)
+		errs = append(errs, e)
+	}
+
+	sortConfigErrors(errs)
+	return errs
+}
+
+// validateDocument checks a single YAML document for syntax errors, unknown keys and type
+// mismatches. ok is false when the document cannot be decoded at all, in which case the
+// semantic checks must be skipped.
+func validateDocument(data []byte, fileName string) (file *ast.File, errs []ConfigError, ok bool) {
+	file, err := parser.ParseBytes(data, 0)
+	if err != nil {
+		return nil, []ConfigError{configErrorFromYAML(fileName, "$", err)}, false
+	}
+
+	// Unknown keys are collected from the AST so that all of them are reported,
+	// not just the first one a strict decoder would stop at.
+	for _, doc := range file.Docs {
+		errs = append(errs, unknownFields(doc.Body, reflect.TypeOf(YAMLConfig{}), "$")...)
+	}
+	for i := range errs {
+		errs[i].File = fileName
+	}
+
+	var cfg YAMLConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		// Type mismatches make the decoded values unreliable, so stop here.
+		return file, append(errs, configErrorFromYAML(fileName, "$", err)), false
+	}
+	return file, errs, true
+}
+
+// validateSemantics resolves references and checks the decoded values.
+// Unresolvable ${ENV} or file: references are reported like any other invalid value.
+func validateSemantics(cfg *YAMLConfig) []ConfigError {
+	errs := resolveConfigReferences(cfg)
+	return append(errs, validateConfig(cfg)...)
+}
+
+// sortConfigErrors orders errors by file, then position.
+func sortConfigErrors(errs []ConfigError) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].File != errs[j].File {
+			return errs[i].File < errs[j].File
+		}
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+}
+
+// validateConfig checks the decoded values. Positions are filled in by the caller.
+func validateConfig(cfg *YAMLConfig) []ConfigError {
+	var errs []ConfigError
+	add := func(path, format string, args ...interface{}) {
+		errs = append(errs, ConfigError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(path, format string, args ...interface{}) {
+		errs = append(errs, ConfigError{Path: path, Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+
+	mc := cfg.MonitorConfig
+	const root = "$.monitor_config"
+
+	if err := validateDuration(mc.APITimeout, true); err != nil {
+		add(root+".api_timeout", "%v", err)
+	}
+	if err := validateDuration(mc.APIProbeInterval, true); err != nil {
+		add(root+".api_probe_interval", "%v", err)
+	}
+	if err := validateListenAddress(mc.MetricsPort); err != nil {
+		add(root+".metrics_port", "%v", err)
+	}
+
+	aws := mc.AWS
+	if aws.Region != "" && !awsRegionPattern.MatchString(aws.Region) {
+		add(root+".aws.region", "invalid AWS region %q", aws.Region)
+	}
+	if (aws.AccessKey == "") != (aws.SecretKey == "") {
+		add(root+".aws", "access_key and secret_key must be set together")
+	}
+	for i, id := range aws.DirectConnect.ConnectionIDs {
+		if !dxConnectionIDPattern.MatchString(id) {
+			add(fmt.Sprintf("%s.aws.direct_connect.connection_ids[%d]", root, i),
+				"invalid Direct Connect connection ID %q (expected dxcon- followed by 8 characters)", id)
+		}
+	}
+	if len(aws.DirectConnect.ConnectionIDs) > 0 && aws.Region == "" {
+		add(root+".aws.region", "region is required when direct_connect.connection_ids is set")
+	}
+	if err := validateDuration(aws.DirectConnect.CollectInterval, false); err != nil {
+		add(root+".aws.direct_connect.collect_interval", "%v", err)
+	}
+	if err := validateDuration(aws.DirectConnect.CollectTimeout, false); err != nil {
+		add(root+".aws.direct_connect.collect_timeout", "%v", err)
+	}
+	if err := validateDuration(mc.AIHealthCheck.Interval, false); err != nil {
+		add(root+".ai_health_check.interval", "%v", err)
+	}
+	if err := validateDuration(mc.AIHealthCheck.Timeout, false); err != nil {
+		add(root+".ai_health_check.timeout", "%v", err)
+	}
+	if _, err := newProxyFunc(mc.AIHealthCheck.Proxy); err != nil {
+		add(root+".ai_health_check.proxy", "%v", err)
+	}
+	if err := validateDuration(mc.Certificates.Interval, false); err != nil {
+		add(root+".certificates.interval", "%v", err)
+	}
+	if err := validateDuration(mc.Certificates.Timeout, false); err != nil {
+		add(root+".certificates.timeout", "%v", err)
+	}
+	if aws.DirectConnect.MetricsLookbackMinutes < 0 {
+		add(root+".aws.direct_connect.metrics_lookback_minutes", "must not be negative")
+	}
+
+	seen := make(map[string]int, len(mc.Probes))
+	for i, p := range mc.Probes {
+		path := fmt.Sprintf("%s.probes[%d]", root, i)
+		if p.Name == "" {
+			add(path, "name is required")
+		} else if first, ok := seen[p.Name]; ok {
+			add(path+".name", "duplicate probe name %q (first defined at probes[%d])", p.Name, first)
+		} else {
+			seen[p.Name] = i
+		}
+		if err := validateDuration(p.Timeout, false); err != nil {
+			add(path+".timeout", "%v", err)
+		}
+		if err := validateDuration(p.Interval, false); err != nil {
+			add(path+".interval", "%v", err)
+		}
+
+		probeFactoriesMu.RLock()
+		factory, known := probeFactories[strings.ToLower(p.Type)]
+		probeFactoriesMu.RUnlock()
+		if !known {
+			add(path+".type", "unknown probe type %q (known types: %s)", p.Type, strings.Join(RegisteredProbeTypes(), ", "))
+			continue
+		}
+		// The factory performs the type-specific checks (URL format, required fields, ...).
+		if _, err := factory(p); err != nil {
+			add(path, "%v", err)
+		}
+		// Check commands are resolved where the monitor runs, which may not be this host
+		if strings.EqualFold(p.Type, "exec") && p.Exec.Command != "" {
+			if _, err := exec.LookPath(p.Exec.Command); err != nil {
+				warn(path+".exec.command", "%v on this host", err)
+			}
+		}
+	}
+
+	// Extra certificate targets share the api_name label space with probes
+	seenTargets := make(map[string]int, len(mc.Certificates.Targets))
+	for i, c := range mc.Certificates.Targets {
+		path := fmt.Sprintf("%s.certificates.targets[%d]", root, i)
+		if c.Name == "" {
+			add(path, "name is required")
+		} else if first, ok := seen[c.Name]; ok {
+			add(path+".name", "duplicate name %q (also used by probes[%d])", c.Name, first)
+		} else if first, ok := seenTargets[c.Name]; ok {
+			add(path+".name", "duplicate name %q (first defined at certificates.targets[%d])", c.Name, first)
+		} else {
+			seenTargets[c.Name] = i
+		}
+		if c.Target == "" {
+			add(path, "target is required")
+		} else if target, err := WithSTARTTLS(c.Target, c.StartTLS); err != nil {
+			add(path+".starttls", "%v", err)
+		} else if _, _, _, _, err := normalizeHostPort(target); err != nil {
+			add(path+".target", "invalid target %q: %v", c.Target, err)
+		}
+		if _, err := newCertificateConnection(c); err != nil {
+			add(path, "%v", err)
+		}
+	}
+
+	return errs
+}
+
+// validateDuration checks that value is a positive Go duration. Empty values are only
+// accepted when the field is optional.
+func validateDuration(value string, required bool) error {
+	if value == "" {
+		if required {
+			return errors.New("is required")
+		}
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("duration must be positive, got %q", value)
+	}
+	return nil
+}
+
+// validateListenAddress checks a listen address such as ":7999" or "0.0.0.0:7999".
+func validateListenAddress(addr string) error {
+	if addr == "" {
+		return errors.New("is required")
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid port %q in %q (must be 1-65535)", port, addr)
+	}
+	return nil
+}
+
+// unknownFields walks node alongside the Go type it decodes into and reports every
+// mapping key that has no matching yaml tag.
+func unknownFields(node ast.Node, t reflect.Type, path string) []ConfigError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch n := node.(type) {
+	case *ast.TagNode:
+		return unknownFields(n.Value, t, path)
+	case *ast.AnchorNode:
+		return unknownFields(n.Value, t, path)
+	case *ast.MappingValueNode:
+		return unknownFields(&ast.MappingNode{Values: []*ast.MappingValueNode{n}}, t, path)
+	case *ast.MappingNode:
+		var errs []ConfigError
+		for _, mv := range n.Values {
+			key := mv.Key.GetToken().Value
+			childPath := path + "." + key
+			switch t.Kind() {
+			case reflect.Struct:
+				field, ok := yamlField(t, key)
+				if !ok {
+					pos := mv.Key.GetToken().Position
+					errs = append(errs, ConfigError{
+						Path:    childPath,
+						Line:    pos.Line,
+						Column:  pos.Column,
+						Message: fmt.Sprintf("unknown field %q", key),
+					})
+					continue
+				}
+				errs = append(errs, unknownFields(mv.Value, field.Type, childPath)...)
+			case reflect.Map:
+				errs = append(errs, unknownFields(mv.Value, t.Elem(), childPath)...)
+			}
+		}
+		return errs
+	case *ast.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		var errs []ConfigError
+		for i, v := range n.Values {
+			errs = append(errs, unknownFields(v, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	}
+	return nil
+}
+
+// yamlField finds the struct field of t that is decoded from key.
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("yaml") == "-" {
+			continue
+		}
+		if yamlFieldName(f) == key {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// positionOf returns the line and column of the node at path, walking up to the nearest
+// existing parent when the value itself is absent from the file.
+func positionOf(file *ast.File, path string) (int, int) {
+	for path != "" && path != "$" {
+		if line, col, found := exactPositionOf(file, path); found {
+			return line, col
+		}
+		cut := strings.LastIndexAny(path, ".[")
+		if cut <= 0 {
+			break
+		}
+		path = path[:cut]
+	}
+	return 0, 0
+}
+
+// exactPositionOf returns the line and column of the node at path, if it exists in file.
+func exactPositionOf(file *ast.File, path string) (int, int, bool) {
+	if file == nil {
+		return 0, 0, false
+	}
+	p, err := yaml.PathString(path)
+	if err != nil {
+		return 0, 0, false
+	}
+	node, err := p.FilterFile(file)
+	if err != nil || node == nil || node.GetToken() == nil {
+		return 0, 0, false
+	}
+	pos := node.GetToken().Position
+	return pos.Line, pos.Column, true
+}
+
+// configErrorFromYAML converts a go-yaml error into a ConfigError, keeping its position when available.
+func configErrorFromYAML(fileName, path string, err error) ConfigError {
+	var yerr yaml.Error
+	if errors.As(err, &yerr) && yerr.GetToken() != nil {
+		pos := yerr.GetToken().Position
+		return ConfigError{File: fileName, Path: path, Line: pos.Line, Column: pos.Column, Message: yerr.GetMessage()}
+	}
+	return ConfigError{File: fileName, Path: path, Message: err.Error()}
+}
@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"testing"
+)
+
+func TestValidateConfigData_ReportsAllProblems(t *testing.T) {
+	data := []byte(`monitor_config:
+  api_timeout: "10x"
+  api_probe_interval: "60s"
+  metrics_port: ":7999"
+  unknown_key: true
+  aws:
+    region: "cn-northwest-1"
+    direct_connect:
+      connection_ids: ["dxcon-abc"]
+  ai_health_check:
+    proxy:
+      url: "ftp://proxy.example.com:21"
+  probes:
+    - name: "bad-url"
+      type: "http"
+      url: "not-a-url"
+`)
+
+	problems := ValidateConfigData(data)
+
+	want := map[string]int{ // path -> line
+		"$.monitor_config.api_timeout":                          2,
+		"$.monitor_config.unknown_key":                          5,
+		"$.monitor_config.aws.direct_connect.connection_ids[0]": 9,
+		"$.monitor_config.ai_health_check.proxy":                12,
+		"$.monitor_config.probes[0]":                            14,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("expected %d problems, got %d: %v", len(want), len(problems), problems)
+	}
+	for _, p := range problems {
+		line, ok := want[p.Path]
+		if !ok {
+			t.Errorf("unexpected problem: %v", p)
+			continue
+		}
+		if p.Line != line {
+			t.Errorf("%s: expected line %d, got %d", p.Path, line, p.Line)
+		}
+	}
+}
+
+func TestValidateConfigData_Valid(t *testing.T) {
+	data := []byte(`monitor_config:
+  api_timeout: "10s"
+  api_probe_interval: "60s"
+  metrics_port: ":7999"
+  probes:
+    - name: "example"
+      type: "http"
+      url: "https://example.com"
+      interval: "30s"
+`)
+
+	if problems := ValidateConfigData(data); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+}
+
+func TestValidateConfigData_MissingExecCommandIsWarning(t *testing.T) {
+	data := []byte(`monitor_config:
+  api_timeout: "10s"
+  api_probe_interval: "60s"
+  metrics_port: ":7999"
+  probes:
+    - name: "disk"
+      type: "exec"
+      exec:
+        command: "no-such-check-command"
+`)
+
+	problems := ValidateConfigData(data)
+	if len(problems) != 1 {
+		t.Fatalf("expected one problem, got %v", problems)
+	}
+	if p := problems[0]; !p.Warning || p.Path != "$.monitor_config.probes[0].exec.command" || p.Line != 9 {
+		t.Fatalf("expected a warning on exec.command at line 9, got %+v", p)
+	}
+}
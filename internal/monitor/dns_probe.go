@@ -0,0 +1,400 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// resolvConfPath is where the default resolver is read from when a dns probe does not set one.
+const resolvConfPath = "/etc/resolv.conf"
+
+// DNSConfig defines the query made by a "dns" probe.
+//
+//	resolver: 8.8.8.8 | udp://8.8.8.8:53 | tcp://8.8.8.8:53 | tls://1.1.1.1:853
+//
+// The resolver defaults to the first nameserver in /etc/resolv.conf. Expected answers are
+// compared as IP addresses for A/AAAA, host names for CNAME/MX/NS/PTR/SOA, "target:port"
+// for SRV and the joined strings for TXT. Without expected answers a NOERROR response must
+// contain at least one record of the queried type.
+type DNSConfig struct {
+	Query           string   `yaml:"query"`            // Name to resolve
+	RecordType      string   `yaml:"record_type"`      // A (default), AAAA, CNAME, MX, NS, PTR, SOA, SRV or TXT
+	Resolver        string   `yaml:"resolver"`         // Optional, see above
+	ServerName      string   `yaml:"server_name"`      // TLS server name for tls:// resolvers, defaults to the resolver host
+	ExpectedRcode   string   `yaml:"expected_rcode"`   // NOERROR (default), NXDOMAIN, SERVFAIL, REFUSED, ...
+	ExpectedAnswers []string `yaml:"expected_answers"` // Values that must all be present in the answer
+}
+
+// DNSResult carries the DNS specific outcome of a dns probe.
+type DNSResult struct {
+	Rcode   string   // Response code, e.g. NOERROR
+	Answers []string // Answers of the queried record type, rendered as described on DNSConfig
+}
+
+// dnsRecordTypes maps the supported record_type values to their wire types.
+var dnsRecordTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"NS":    dnsmessage.TypeNS,
+	"PTR":   dnsmessage.TypePTR,
+	"SOA":   dnsmessage.TypeSOA,
+	"SRV":   dnsmessage.TypeSRV,
+	"TXT":   dnsmessage.TypeTXT,
+}
+
+// dnsRcodeNames maps response codes to their conventional names.
+var dnsRcodeNames = map[dnsmessage.RCode]string{
+	dnsmessage.RCodeSuccess:        "NOERROR",
+	dnsmessage.RCodeFormatError:    "FORMERR",
+	dnsmessage.RCodeServerFailure:  "SERVFAIL",
+	dnsmessage.RCodeNameError:      "NXDOMAIN",
+	dnsmessage.RCodeNotImplemented: "NOTIMP",
+	dnsmessage.RCodeRefused:        "REFUSED",
+}
+
+// DNSProbe is an implementation of ProbeExecutor that resolves a name against a DNS server.
+type DNSProbe struct {
+	Name            string
+	Query           dnsmessage.Name
+	RecordType      dnsmessage.Type
+	Network         string // udp, tcp or tls
+	Server          string // host:port of the resolver
+	TLSConfig       *tls.Config
+	ExpectedRcode   dnsmessage.RCode
+	ExpectedAnswers []string
+}
+
+// newDNSProbeFromConfig builds a DNSProbe for the "dns" probe type.
+func newDNSProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if err := rejectProxy(cfg.Proxy, "dns"); err != nil {
+		return nil, err
+	}
+	dc := cfg.DNS
+	if dc.Query == "" {
+		return nil, fmt.Errorf("dns.query is required")
+	}
+	query, err := dnsmessage.NewName(dnsFQDN(dc.Query))
+	if err != nil {
+		return nil, fmt.Errorf("invalid dns.query %q: %w", dc.Query, err)
+	}
+
+	recordType := strings.ToUpper(dc.RecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+	qtype, ok := dnsRecordTypes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dns.record_type %q", dc.RecordType)
+	}
+
+	rcode := dnsmessage.RCodeSuccess
+	if dc.ExpectedRcode != "" {
+		if rcode, ok = parseDNSRcode(dc.ExpectedRcode); !ok {
+			return nil, fmt.Errorf("unknown dns.expected_rcode %q", dc.ExpectedRcode)
+		}
+	}
+
+	network, server, err := parseDNSResolver(dc.Resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	probe := &DNSProbe{
+		Name:          cfg.Name,
+		Query:         query,
+		RecordType:    qtype,
+		Network:       network,
+		Server:        server,
+		ExpectedRcode: rcode,
+	}
+	if network == "tls" {
+		serverName := dc.ServerName
+		if serverName == "" {
+			serverName, _, _ = net.SplitHostPort(server)
+		}
+		probe.TLSConfig = &tls.Config{ServerName: serverName}
+	}
+	for _, answer := range dc.ExpectedAnswers {
+		probe.ExpectedAnswers = append(probe.ExpectedAnswers, normalizeDNSAnswer(qtype, answer))
+	}
+	return probe, nil
+}
+
+// Execute implements the ProbeExecutor interface, resolving the configured name once.
+// A truncated UDP response is retried over TCP, as a stub resolver would.
+func (p *DNSProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	start := time.Now()
+	resp, err := p.exchange(ctx, p.Network)
+	if err == nil && resp.Header.Truncated && p.Network == "udp" {
+		resp, err = p.exchange(ctx, "tcp")
+	}
+	latency := time.Since(start).Seconds()
+	if err != nil {
+		err = fmt.Errorf("dns query %s %s via %s://%s failed: %w", dnsTypeName(p.RecordType), p.Query, p.Network, p.Server, err)
+		return NewProbeResult(p.Name, 0, latency, 0, err), err
+	}
+
+	answers := p.answers(resp)
+	result := NewProbeResult(p.Name, 1, latency, 0, nil)
+	result.DNS = &DNSResult{Rcode: dnsRcodeName(resp.Header.RCode), Answers: answers}
+	if msg := p.check(resp.Header.RCode, answers); msg != "" {
+		result.Status = 0
+		result.Message = msg
+	}
+	return result, nil
+}
+
+// check compares a response with the expectations and returns why it was rejected, if it was.
+func (p *DNSProbe) check(rcode dnsmessage.RCode, answers []string) string {
+	if rcode != p.ExpectedRcode {
+		return fmt.Sprintf("rcode %s, expected %s", dnsRcodeName(rcode), dnsRcodeName(p.ExpectedRcode))
+	}
+	if len(p.ExpectedAnswers) == 0 {
+		if rcode == dnsmessage.RCodeSuccess && len(answers) == 0 {
+			return fmt.Sprintf("no %s records for %s", dnsTypeName(p.RecordType), p.Query)
+		}
+		return ""
+	}
+
+	got := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		got[a] = true
+	}
+	var missing []string
+	for _, want := range p.ExpectedAnswers {
+		if !got[want] {
+			missing = append(missing, want)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("answers %v do not include expected %v", answers, missing)
+	}
+	return ""
+}
+
+// exchange sends the query over network and waits for the matching response.
+func (p *DNSProbe) exchange(ctx context.Context, network string) (*dnsmessage.Message, error) {
+	id := uint16(rand.Uint32())
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{
+			{Name: p.Query, Type: p.RecordType, Class: dnsmessage.ClassINET},
+		},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := p.dial(ctx, network)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Unblock reads as soon as the probe is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if network == "udp" {
+		if _, err := conn.Write(packed); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 65535)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return nil, err
+			}
+			var resp dnsmessage.Message
+			// Ignore stray datagrams that do not answer this query
+			if resp.Unpack(buf[:n]) != nil || resp.Header.ID != id || !resp.Header.Response {
+				continue
+			}
+			return &resp, nil
+		}
+	}
+
+	// TCP and TLS messages are prefixed with their length
+	frame := make([]byte, 2+len(packed))
+	binary.BigEndian.PutUint16(frame, uint16(len(packed)))
+	copy(frame[2:], packed)
+	if _, err := conn.Write(frame); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if resp.Header.ID != id || !resp.Header.Response {
+		return nil, fmt.Errorf("response does not match query")
+	}
+	return &resp, nil
+}
+
+// dial connects to the resolver.
+func (p *DNSProbe) dial(ctx context.Context, network string) (net.Conn, error) {
+	if network == "tls" {
+		dialer := &tls.Dialer{Config: p.TLSConfig}
+		return dialer.DialContext(ctx, "tcp", p.Server)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, p.Server)
+}
+
+// answers renders the answer records of the queried type.
+func (p *DNSProbe) answers(resp *dnsmessage.Message) []string {
+	var answers []string
+	for _, rr := range resp.Answers {
+		if rr.Header.Type != p.RecordType {
+			continue
+		}
+		var value string
+		switch body := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			value = netip.AddrFrom4(body.A).String()
+		case *dnsmessage.AAAAResource:
+			value = netip.AddrFrom16(body.AAAA).String()
+		case *dnsmessage.CNAMEResource:
+			value = body.CNAME.String()
+		case *dnsmessage.MXResource:
+			value = body.MX.String()
+		case *dnsmessage.NSResource:
+			value = body.NS.String()
+		case *dnsmessage.PTRResource:
+			value = body.PTR.String()
+		case *dnsmessage.SOAResource:
+			value = body.NS.String()
+		case *dnsmessage.SRVResource:
+			value = net.JoinHostPort(body.Target.String(), fmt.Sprint(body.Port))
+		case *dnsmessage.TXTResource:
+			value = strings.Join(body.TXT, "")
+		default:
+			continue
+		}
+		answers = append(answers, normalizeDNSAnswer(p.RecordType, value))
+	}
+	return answers
+}
+
+// normalizeDNSAnswer puts an answer of type qtype into a canonical form so configured and
+// received values compare equal.
+func normalizeDNSAnswer(qtype dnsmessage.Type, value string) string {
+	switch qtype {
+	case dnsmessage.TypeA, dnsmessage.TypeAAAA:
+		if addr, err := netip.ParseAddr(value); err == nil {
+			return addr.String()
+		}
+	case dnsmessage.TypeTXT:
+		return value
+	case dnsmessage.TypeSRV:
+		if host, port, err := net.SplitHostPort(value); err == nil {
+			return strings.ToLower(strings.TrimSuffix(host, ".")) + ":" + port
+		}
+	}
+	return strings.ToLower(strings.TrimSuffix(value, "."))
+}
+
+// parseDNSResolver splits a resolver setting into the network and the host:port to query.
+func parseDNSResolver(resolver string) (network, server string, err error) {
+	if resolver == "" {
+		if resolver, err = systemDNSResolver(); err != nil {
+			return "", "", err
+		}
+	}
+
+	network, port := "udp", "53"
+	if scheme, rest, ok := strings.Cut(resolver, "://"); ok {
+		switch strings.ToLower(scheme) {
+		case "udp", "tcp":
+			network = strings.ToLower(scheme)
+		case "tls":
+			network, port = "tls", "853"
+		default:
+			return "", "", fmt.Errorf("unsupported dns.resolver scheme %q (expected udp, tcp or tls)", scheme)
+		}
+		resolver = rest
+	}
+
+	host, p, splitErr := net.SplitHostPort(resolver)
+	if splitErr != nil {
+		host, p = strings.Trim(resolver, "[]"), port
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("invalid dns.resolver %q: missing host", resolver)
+	}
+	return network, net.JoinHostPort(host, p), nil
+}
+
+// systemDNSResolver returns the first nameserver listed in /etc/resolv.conf.
+func systemDNSResolver() (string, error) {
+	f, err := os.Open(resolvConfPath)
+	if err != nil {
+		return "", fmt.Errorf("dns.resolver not set and system resolver unavailable: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return fields[1], nil
+		}
+	}
+	return "", fmt.Errorf("dns.resolver not set and no nameserver found in %s", resolvConfPath)
+}
+
+// parseDNSRcode parses a response code name such as NXDOMAIN.
+func parseDNSRcode(name string) (dnsmessage.RCode, bool) {
+	for code, n := range dnsRcodeNames {
+		if strings.EqualFold(n, name) {
+			return code, true
+		}
+	}
+	return 0, false
+}
+
+// dnsRcodeName returns the conventional name of a response code.
+func dnsRcodeName(rcode dnsmessage.RCode) string {
+	if name, ok := dnsRcodeNames[rcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// dnsTypeName returns the record type as written in the configuration, e.g. AAAA.
+func dnsTypeName(t dnsmessage.Type) string {
+	return strings.TrimPrefix(t.String(), "Type")
+}
+
+// dnsFQDN appends the root label to name if it is missing.
+func dnsFQDN(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
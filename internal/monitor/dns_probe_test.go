@@ -0,0 +1,197 @@
+package monitor
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startTestDNSServer answers A queries for api.example.com on UDP and TCP at the same address.
+// UDP answers are truncated when truncateUDP is set so clients have to retry over TCP.
+func startTestDNSServer(t *testing.T, truncateUDP bool) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		pc.Close()
+		t.Skipf("tcp port unavailable: %v", err)
+	}
+	t.Cleanup(func() { pc.Close(); ln.Close() })
+
+	answer := func(req []byte, truncate bool) []byte {
+		var q dnsmessage.Message
+		if err := q.Unpack(req); err != nil || len(q.Questions) != 1 {
+			return nil
+		}
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: q.Header.ID, Response: true, Truncated: truncate},
+			Questions: q.Questions,
+		}
+		question := q.Questions[0]
+		switch {
+		case truncate:
+		case strings.EqualFold(question.Name.String(), "api.example.com.") && question.Type == dnsmessage.TypeA:
+			for _, ip := range [][4]byte{{192, 0, 2, 10}, {192, 0, 2, 11}} {
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AResource{A: ip},
+				})
+			}
+		case strings.EqualFold(question.Name.String(), "api.example.com."):
+			// Name exists, but has no records of this type
+		default:
+			resp.Header.RCode = dnsmessage.RCodeNameError
+		}
+		packed, _ := resp.Pack()
+		return packed
+	}
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(answer(buf[:n], truncateUDP), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var length [2]byte
+				if _, err := io.ReadFull(conn, length[:]); err != nil {
+					return
+				}
+				req := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, req); err != nil {
+					return
+				}
+				resp := answer(req, false)
+				binary.BigEndian.PutUint16(length[:], uint16(len(resp)))
+				conn.Write(append(length[:], resp...))
+			}()
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func runDNSProbe(t *testing.T, dns DNSConfig) ProbeResult {
+	t.Helper()
+	probe, err := NewProbeFromConfig(ProbeConfig{Name: "dns-test", Type: "dns", DNS: dns})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := probe.Execute(ctx)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	return result
+}
+
+func TestDNSProbe(t *testing.T) {
+	server := startTestDNSServer(t, false)
+
+	tests := []struct {
+		name       string
+		dns        DNSConfig
+		wantStatus int
+		wantCount  int
+		wantMsg    string
+	}{
+		{
+			name:       "expected answers present",
+			dns:        DNSConfig{Query: "api.example.com", ExpectedAnswers: []string{"192.0.2.11"}},
+			wantStatus: 1,
+			wantCount:  2,
+		},
+		{
+			name:       "any answer accepted",
+			dns:        DNSConfig{Query: "API.example.com.", RecordType: "a", Resolver: "tcp://" + server},
+			wantStatus: 1,
+			wantCount:  2,
+		},
+		{
+			name:      "wrong answer",
+			dns:       DNSConfig{Query: "api.example.com", ExpectedAnswers: []string{"198.51.100.1"}},
+			wantCount: 2,
+			wantMsg:   "do not include expected [198.51.100.1]",
+		},
+		{
+			name:    "unexpected rcode",
+			dns:     DNSConfig{Query: "missing.example.com"},
+			wantMsg: "rcode NXDOMAIN, expected NOERROR",
+		},
+		{
+			name:       "expected NXDOMAIN",
+			dns:        DNSConfig{Query: "missing.example.com", ExpectedRcode: "nxdomain"},
+			wantStatus: 1,
+		},
+		{
+			name:    "no records of type",
+			dns:     DNSConfig{Query: "api.example.com", RecordType: "AAAA"},
+			wantMsg: "no AAAA records for api.example.com.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.dns.Resolver == "" {
+				tt.dns.Resolver = server
+			}
+			result := runDNSProbe(t, tt.dns)
+			if result.Status != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (message %q)", result.Status, tt.wantStatus, result.Message)
+			}
+			if result.DNS == nil || len(result.DNS.Answers) != tt.wantCount {
+				t.Fatalf("unexpected DNS result %+v", result.DNS)
+			}
+			if !strings.Contains(result.Message, tt.wantMsg) {
+				t.Fatalf("message %q does not contain %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestDNSProbe_TruncatedUDPRetriesOverTCP(t *testing.T) {
+	server := startTestDNSServer(t, true)
+	result := runDNSProbe(t, DNSConfig{Query: "api.example.com", Resolver: "udp://" + server})
+	if result.Status != 1 || len(result.DNS.Answers) != 2 {
+		t.Fatalf("expected TCP fallback to succeed, got status %d, %+v: %s", result.Status, result.DNS, result.Message)
+	}
+}
+
+func TestParseDNSResolver(t *testing.T) {
+	tests := []struct {
+		resolver, network, server string
+	}{
+		{"8.8.8.8", "udp", "8.8.8.8:53"},
+		{"tcp://8.8.8.8:5353", "tcp", "8.8.8.8:5353"},
+		{"tls://1.1.1.1", "tls", "1.1.1.1:853"},
+		{"udp://[2001:db8::1]", "udp", "[2001:db8::1]:53"},
+	}
+	for _, tt := range tests {
+		network, server, err := parseDNSResolver(tt.resolver)
+		if err != nil || network != tt.network || server != tt.server {
+			t.Errorf("parseDNSResolver(%q) = %q, %q, %v; want %q, %q", tt.resolver, network, server, err, tt.network, tt.server)
+		}
+	}
+	if _, _, err := parseDNSResolver("https://dns.google"); err == nil {
+		t.Error("expected an error for an unsupported scheme")
+	}
+}
@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Nagios plugin states, as returned in the exit code of a check command.
+const (
+	NagiosOK       = 0
+	NagiosWarning  = 1
+	NagiosCritical = 2
+	NagiosUnknown  = 3
+)
+
+// nagiosStateNames maps a plugin state to its name.
+var nagiosStateNames = map[int]string{
+	NagiosOK:       "OK",
+	NagiosWarning:  "WARNING",
+	NagiosCritical: "CRITICAL",
+	NagiosUnknown:  "UNKNOWN",
+}
+
+// execWaitDelay bounds how long output pipes are drained after a timed out command is killed,
+// in case it left children holding them open.
+const execWaitDelay = time.Second
+
+// ExecConfig defines the command run by an "exec" probe. The command follows the Nagios plugin
+// conventions: exit code 0/1/2/3 for OK/WARNING/CRITICAL/UNKNOWN and output of the form
+// "TEXT | perfdata".
+type ExecConfig struct {
+	Command string            `yaml:"command"` // Executable path, or name looked up in PATH when the check runs
+	Args    []string          `yaml:"args"`
+	Env     map[string]string `yaml:"env" secret:"true"` // Added to the monitor's environment
+	Dir     string            `yaml:"dir"`               // Working directory, the monitor's if empty
+}
+
+// ExecResult carries the plugin specific outcome of an exec probe.
+type ExecResult struct {
+	State    int // Nagios state derived from the exit code, exit codes above 3 are UNKNOWN
+	Perfdata []Perfdata
+}
+
+// Perfdata is one performance data value reported by a plugin.
+type Perfdata struct {
+	Label string
+	Value float64
+	UOM   string // Unit of measurement as reported, e.g. s, ms, %, B, KB, c
+}
+
+// ExecProbe is an implementation of ProbeExecutor that runs a Nagios compatible check command.
+// OK and WARNING are reported as up, CRITICAL and UNKNOWN as down. The first line of output
+// is the result message.
+type ExecProbe struct {
+	Name    string
+	Command string
+	Args    []string
+	Env     []string
+	Dir     string
+}
+
+// newExecProbeFromConfig builds an ExecProbe for the "exec" probe type.
+func newExecProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if err := rejectProxy(cfg.Proxy, "exec"); err != nil {
+		return nil, err
+	}
+	ec := cfg.Exec
+	if ec.Command == "" {
+		return nil, fmt.Errorf("exec.command is required")
+	}
+	probe := &ExecProbe{Name: cfg.Name, Command: ec.Command, Args: ec.Args, Dir: ec.Dir}
+	if len(ec.Env) > 0 {
+		keys := make([]string, 0, len(ec.Env))
+		for key := range ec.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		probe.Env = os.Environ()
+		for _, key := range keys {
+			probe.Env = append(probe.Env, key+"="+ec.Env[key])
+		}
+	}
+	return probe, nil
+}
+
+// Execute implements the ProbeExecutor interface. A command that cannot be started or is
+// killed by the context is a probe error; any exit code is a verdict.
+func (p *ExecProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Env = p.Env
+	cmd.Dir = p.Dir
+	cmd.WaitDelay = execWaitDelay
+	var output bytes.Buffer
+	cmd.Stdout = &output
+
+	start := time.Now()
+	err := cmd.Run()
+	latency := time.Since(start).Seconds()
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		err = fmt.Errorf("command %s did not finish: %w", p.Command, ctx.Err())
+		return NewProbeResult(p.Name, 0, latency, 0, err), err
+	case err != nil && !errors.As(err, &exitErr):
+		err = fmt.Errorf("failed to run %s: %w", p.Command, err)
+		return NewProbeResult(p.Name, 0, latency, 0, err), err
+	}
+
+	state := cmd.ProcessState.ExitCode()
+	if state < NagiosOK || state > NagiosUnknown {
+		state = NagiosUnknown
+	}
+	text, perfdata := parsePluginOutput(output.String())
+
+	result := NewProbeResult(p.Name, 0, latency, state, nil)
+	result.Exec = &ExecResult{State: state, Perfdata: perfdata}
+	result.Message = nagiosStateNames[state]
+	if text != "" {
+		result.Message += ": " + text
+	}
+	if state == NagiosOK || state == NagiosWarning {
+		result.Status = 1
+	}
+	return result, nil
+}
+
+// parsePluginOutput splits plugin output into the first line of text and the perfdata found
+// after "|" on the first line and in the long output.
+func parsePluginOutput(output string) (string, []Perfdata) {
+	first, long, _ := strings.Cut(output, "\n")
+	text, perf, _ := strings.Cut(first, "|")
+	if _, longPerf, ok := strings.Cut(long, "|"); ok {
+		perf += " " + longPerf
+	}
+	return strings.TrimSpace(text), parsePerfdata(perf)
+}
+
+// parsePerfdata parses space separated 'label'=value[UOM];[warn];[crit];[min];[max] entries.
+// Entries that cannot be parsed, or whose value is U (undetermined), are skipped.
+func parsePerfdata(s string) []Perfdata {
+	var perfdata []Perfdata
+	for _, entry := range splitPerfdata(s) {
+		label, rest, ok := strings.Cut(entry, "=")
+		if !ok || label == "" {
+			continue
+		}
+		if strings.HasPrefix(label, "'") && strings.HasSuffix(label, "'") && len(label) >= 2 {
+			label = strings.ReplaceAll(label[1:len(label)-1], "''", "'")
+		}
+		value, _, _ := strings.Cut(rest, ";")
+		number := strings.TrimRightFunc(value, func(r rune) bool {
+			return !(r >= '0' && r <= '9') && r != '.'
+		})
+		v, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			continue
+		}
+		perfdata = append(perfdata, Perfdata{Label: label, Value: v, UOM: value[len(number):]})
+	}
+	return perfdata
+}
+
+// splitPerfdata splits perfdata on whitespace, keeping quoted labels that contain spaces together.
+func splitPerfdata(s string) []string {
+	var entries []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+			current.WriteRune(r)
+		case (r == ' ' || r == '\t' || r == '\n' || r == '\r') && !quoted:
+			if current.Len() > 0 {
+				entries = append(entries, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		entries = append(entries, current.String())
+	}
+	return entries
+}
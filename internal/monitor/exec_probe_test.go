@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExecProbe(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantStatus int
+		wantState  int
+		wantMsg    string
+	}{
+		{
+			name:       "ok",
+			script:     `echo "HTTP OK - 0.1s | time=0.1s;1;2;0 size=512B"; echo "line two | 'rows read'=42"`,
+			wantStatus: 1, wantState: NagiosOK, wantMsg: "OK: HTTP OK - 0.1s",
+		},
+		{name: "warning", script: `echo "DISK WARNING - 85% used"; exit 1`, wantStatus: 1, wantState: NagiosWarning, wantMsg: "WARNING: DISK WARNING - 85% used"},
+		{name: "critical", script: `echo "PROCS CRITICAL: $PROCESS not running"; exit 2`, wantState: NagiosCritical, wantMsg: "CRITICAL: PROCS CRITICAL: nginx not running"},
+		{name: "unknown exit code", script: `exit 7`, wantState: NagiosUnknown, wantMsg: "UNKNOWN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe, err := NewProbeFromConfig(ProbeConfig{
+				Name: "check",
+				Type: "exec",
+				Exec: ExecConfig{Command: "sh", Args: []string{"-c", tt.script}, Env: map[string]string{"PROCESS": "nginx"}},
+			})
+			if err != nil {
+				t.Fatalf("NewProbeFromConfig failed: %v", err)
+			}
+			result, err := probe.Execute(context.Background())
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Exec.State != tt.wantState || result.StatusCode != tt.wantState || result.Message != tt.wantMsg {
+				t.Fatalf("got status %d, state %d, message %q", result.Status, result.Exec.State, result.Message)
+			}
+			if tt.name == "ok" {
+				want := []Perfdata{{"time", 0.1, "s"}, {"size", 512, "B"}, {"rows read", 42, ""}}
+				if !reflect.DeepEqual(result.Exec.Perfdata, want) {
+					t.Errorf("perfdata = %+v, want %+v", result.Exec.Perfdata, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExecProbe_Timeout(t *testing.T) {
+	probe, err := NewProbeFromConfig(ProbeConfig{Name: "slow", Type: "exec", Exec: ExecConfig{Command: "sleep", Args: []string{"5"}}})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := probe.Execute(ctx); err == nil {
+		t.Fatal("expected an error for a command exceeding the timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("command was not killed on timeout, took %s", elapsed)
+	}
+
+}
+
+func TestExecProbe_MissingCommand(t *testing.T) {
+	// The command is looked up when the check runs, so configurations stay valid on hosts without it
+	probe, err := NewProbeFromConfig(ProbeConfig{Name: "missing", Type: "exec", Exec: ExecConfig{Command: "no-such-check-command"}})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	if result, err := probe.Execute(context.Background()); err == nil || result.Status != 0 {
+		t.Fatalf("expected an error for a command that does not exist, got status %d", result.Status)
+	}
+}
+
+func TestParsePerfdata(t *testing.T) {
+	got := parsePerfdata(`'it''s'=1 load1=0.52;5;10;0 bad pending=U;1;2 temp=-3.5C   latency=12.5ms;;;`)
+	want := []Perfdata{{"it's", 1, ""}, {"load1", 0.52, ""}, {"temp", -3.5, "C"}, {"latency", 12.5, "ms"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parsePerfdata = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,143 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCConfig defines the health check made by a "grpc" probe.
+// The connection is plaintext unless the probe's tls.enabled is set.
+type GRPCConfig struct {
+	Address  string            `yaml:"address"`                // host:port or any gRPC target such as dns:///svc:443
+	Service  string            `yaml:"service"`                // Service name passed to Check, empty for the whole server
+	Metadata map[string]string `yaml:"metadata" secret:"true"` // Request metadata, e.g. authorization
+}
+
+// GRPCResult carries the gRPC specific outcome of a grpc probe.
+type GRPCResult struct {
+	ServingStatus string // SERVING, NOT_SERVING, UNKNOWN or SERVICE_UNKNOWN
+}
+
+// GRPCProbe is an implementation of ProbeExecutor that calls grpc.health.v1.Health/Check.
+type GRPCProbe struct {
+	Name        string
+	Address     string
+	Service     string
+	Credentials credentials.TransportCredentials
+	Metadata    metadata.MD
+}
+
+// newGRPCProbeFromConfig builds a GRPCProbe for the "grpc" probe type.
+func newGRPCProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if err := rejectProxy(cfg.Proxy, "grpc"); err != nil {
+		return nil, err
+	}
+	gc := cfg.GRPC
+	if gc.Address == "" {
+		return nil, fmt.Errorf("grpc.address is required")
+	}
+
+	creds := insecure.NewCredentials()
+	if cfg.TLS.Enabled {
+		tlsConfig, err := newTLSClientConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	} else if cfg.TLS != (TLSConfig{}) {
+		return nil, fmt.Errorf("tls settings require tls.enabled")
+	}
+
+	return &GRPCProbe{
+		Name:        cfg.Name,
+		Address:     gc.Address,
+		Service:     gc.Service,
+		Credentials: creds,
+		Metadata:    metadata.New(gc.Metadata),
+	}, nil
+}
+
+// Execute implements the ProbeExecutor interface. Each check uses a fresh connection so
+// connection and handshake failures are detected on every run.
+func (p *GRPCProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	start := time.Now()
+	creds := &handshakeRecorder{TransportCredentials: p.Credentials}
+	conn, err := grpc.NewClient(p.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		err = fmt.Errorf("grpc client for %s: %w", p.Address, err)
+		return NewProbeResult(p.Name, 0, 0, 0, err), err
+	}
+	defer conn.Close()
+
+	if len(p.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, p.Metadata)
+	}
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: p.Service})
+	latency := time.Since(start).Seconds()
+
+	servingStatus := resp.GetStatus()
+	if err != nil {
+		// The standard health server answers NotFound for services it does not know about
+		if status.Code(err) != codes.NotFound {
+			// The status only carries the handshake error as text, so verification failures
+			// are classified from the error recorded during the handshake.
+			if tlsErr := classifyTLSError(creds.handshakeError()); errors.As(tlsErr, new(*TLSVerificationError)) {
+				err = tlsErr
+			}
+			err = fmt.Errorf("grpc health check %s failed: %w", p.Address, err)
+			return NewProbeResult(p.Name, 0, latency, 0, err), err
+		}
+		servingStatus = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+
+	result := NewProbeResult(p.Name, 0, latency, int(servingStatus), nil)
+	result.GRPC = &GRPCResult{ServingStatus: servingStatus.String()}
+	if servingStatus == healthpb.HealthCheckResponse_SERVING {
+		result.Status = 1
+	} else {
+		result.Message = fmt.Sprintf("service %q is %s", p.Service, servingStatus)
+	}
+	return result, nil
+}
+
+// handshakeRecorder wraps transport credentials to keep the last client handshake error.
+type handshakeRecorder struct {
+	credentials.TransportCredentials
+	mu  sync.Mutex
+	err error
+}
+
+// ClientHandshake implements credentials.TransportCredentials.
+func (c *handshakeRecorder) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, rawConn)
+	if err != nil {
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+	}
+	return conn, info, err
+}
+
+// Clone implements credentials.TransportCredentials. The clone records its own handshakes.
+func (c *handshakeRecorder) Clone() credentials.TransportCredentials {
+	return &handshakeRecorder{TransportCredentials: c.TransportCredentials.Clone()}
+}
+
+// handshakeError returns the last client handshake error, if any.
+func (c *handshakeRecorder) handshakeError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
@@ -0,0 +1,149 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// startTestGRPCServer serves the standard health service and requires the "x-probe" metadata header.
+func startTestGRPCServer(t *testing.T, opts ...grpc.ServerOption) (string, *health.Server) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	requireMetadata := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, _ := metadata.FromIncomingContext(ctx); len(md.Get("x-probe")) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "missing x-probe metadata")
+		}
+		return handler(ctx, req)
+	}
+	server := grpc.NewServer(append(opts, grpc.UnaryInterceptor(requireMetadata))...)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+	return ln.Addr().String(), healthServer
+}
+
+func runGRPCProbe(t *testing.T, cfg GRPCConfig) (ProbeResult, error) {
+	t.Helper()
+	return runGRPCProbeConfig(t, ProbeConfig{GRPC: cfg})
+}
+
+// runGRPCProbeConfig runs a grpc probe built from cfg, which needs no name or type.
+func runGRPCProbeConfig(t *testing.T, cfg ProbeConfig) (ProbeResult, error) {
+	t.Helper()
+	cfg.Name, cfg.Type = "grpc-test", "grpc"
+	probe, err := NewProbeFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return probe.Execute(ctx)
+}
+
+func TestGRPCProbe(t *testing.T) {
+	address, healthServer := startTestGRPCServer(t)
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+	md := map[string]string{"x-probe": "1"}
+
+	tests := []struct {
+		name       string
+		service    string
+		wantStatus int
+		wantCode   healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{name: "server serving", wantStatus: 1, wantCode: healthpb.HealthCheckResponse_SERVING},
+		{name: "service not serving", service: "orders", wantCode: healthpb.HealthCheckResponse_NOT_SERVING},
+		{name: "unknown service", service: "billing", wantCode: healthpb.HealthCheckResponse_SERVICE_UNKNOWN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runGRPCProbe(t, GRPCConfig{Address: address, Service: tt.service, Metadata: md})
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result.Status != tt.wantStatus || result.StatusCode != int(tt.wantCode) || result.GRPC.ServingStatus != tt.wantCode.String() {
+				t.Fatalf("got status %d code %d (%+v), want %d %s", result.Status, result.StatusCode, result.GRPC, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+
+	if _, err := runGRPCProbe(t, GRPCConfig{Address: address}); err == nil || !strings.Contains(err.Error(), "Unauthenticated") {
+		t.Fatalf("expected an Unauthenticated error without metadata, got %v", err)
+	}
+}
+
+func TestGRPCProbe_MutualTLS(t *testing.T) {
+	serverCert := newTestCertificate(t, "server")
+	clientCert := newTestCertificate(t, "client")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert.tls.Leaf)
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert.tls},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	})
+	address, _ := startTestGRPCServer(t, grpc.Creds(creds))
+
+	cfg := ProbeConfig{
+		GRPC: GRPCConfig{Address: address, Metadata: map[string]string{"x-probe": "1"}},
+		TLS:  TLSConfig{Enabled: true, CAFile: serverCert.certFile, CertFile: clientCert.certFile, KeyFile: clientCert.keyFile},
+	}
+	result, err := runGRPCProbeConfig(t, cfg)
+	if err != nil || result.Status != 1 {
+		t.Fatalf("expected mTLS health check to succeed, got status %d, err %v", result.Status, err)
+	}
+
+	noClientCert := cfg
+	noClientCert.TLS.CertFile, noClientCert.TLS.KeyFile = "", ""
+	if _, err := runGRPCProbeConfig(t, noClientCert); err == nil || errors.As(err, new(*TLSVerificationError)) {
+		t.Fatalf("expected a non-verification failure without a client certificate, got %v", err)
+	}
+
+	untrusted := cfg
+	untrusted.TLS.CAFile = ""
+	if _, err := runGRPCProbeConfig(t, untrusted); !errors.As(err, new(*TLSVerificationError)) {
+		t.Fatalf("expected a TLS verification failure for an untrusted server, got %v", err)
+	}
+
+	plaintext := cfg
+	plaintext.TLS.Enabled = false
+	if _, err := NewProbeFromConfig(ProbeConfig{Name: "grpc-test", Type: "grpc", GRPC: plaintext.GRPC, TLS: plaintext.TLS}); err == nil {
+		t.Fatal("expected an error for tls settings without tls.enabled")
+	}
+}
+
+func TestHandshakeRecorder_CloneRecordsSeparately(t *testing.T) {
+	recorder := &handshakeRecorder{TransportCredentials: credentials.NewTLS(&tls.Config{})}
+	clone := recorder.Clone().(*handshakeRecorder)
+	if clone == recorder {
+		t.Fatal("Clone returned the recorder itself")
+	}
+
+	client, server := net.Pipe()
+	defer server.Close()
+	server.Close() // The handshake fails immediately
+	if _, _, err := clone.ClientHandshake(context.Background(), "example.com", client); err == nil {
+		t.Fatal("expected the handshake to fail")
+	}
+	if clone.handshakeError() == nil || recorder.handshakeError() != nil {
+		t.Fatalf("clone error %v, original error %v: want only the clone to record", clone.handshakeError(), recorder.handshakeError())
+	}
+}
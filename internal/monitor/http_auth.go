@@ -0,0 +1,190 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauth2ExpiryMargin is how long before its expiry a cached OAuth2 token is renewed.
+const oauth2ExpiryMargin = 30 * time.Second
+
+// AuthConfig defines how an HTTP probe authenticates.
+//
+//	type: basic | bearer | oauth2
+//
+// oauth2 uses the client credentials grant against token_url; the token is cached until
+// shortly before it expires.
+type AuthConfig struct {
+	Type         string   `yaml:"type"`
+	Username     string   `yaml:"username"`                    // basic
+	Password     string   `yaml:"password" secret:"true"`      // basic
+	Token        string   `yaml:"token" secret:"true"`         // bearer
+	TokenURL     string   `yaml:"token_url"`                   // oauth2
+	ClientID     string   `yaml:"client_id"`                   // oauth2
+	ClientSecret string   `yaml:"client_secret" secret:"true"` // oauth2
+	Scopes       []string `yaml:"scopes"`                      // oauth2, optional
+	ClientAuth   string   `yaml:"client_auth"`                 // oauth2: header (default, HTTP Basic) or body
+}
+
+// requestAuthenticator adds credentials to an outgoing probe request.
+type requestAuthenticator interface {
+	authorize(ctx context.Context, req *http.Request) error
+}
+
+// basicAuthenticator sends HTTP Basic credentials.
+type basicAuthenticator struct {
+	username, password string
+}
+
+func (a *basicAuthenticator) authorize(_ context.Context, req *http.Request) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+// bearerAuthenticator sends a static bearer token.
+type bearerAuthenticator struct {
+	token string
+}
+
+func (a *bearerAuthenticator) authorize(_ context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+// oauth2Authenticator obtains and caches tokens with the OAuth2 client credentials grant.
+type oauth2Authenticator struct {
+	client       *http.Client
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	authInBody   bool
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // Zero when the token does not expire
+}
+
+// newRequestAuthenticator builds the authenticator for cfg, or nil when no auth is configured.
+// Token requests are sent through client.
+func newRequestAuthenticator(cfg AuthConfig, client *http.Client) (requestAuthenticator, error) {
+	switch strings.ToLower(cfg.Type) {
+	case "":
+		if cfg.Username != "" || cfg.Password != "" || cfg.Token != "" || cfg.TokenURL != "" ||
+			cfg.ClientID != "" || cfg.ClientSecret != "" || len(cfg.Scopes) > 0 || cfg.ClientAuth != "" {
+			return nil, fmt.Errorf("auth.type is required when auth settings are present")
+		}
+		return nil, nil
+	case "basic":
+		if cfg.Username == "" {
+			return nil, fmt.Errorf("basic auth requires auth.username")
+		}
+		return &basicAuthenticator{username: cfg.Username, password: cfg.Password}, nil
+	case "bearer":
+		if cfg.Token == "" {
+			return nil, fmt.Errorf("bearer auth requires auth.token")
+		}
+		return &bearerAuthenticator{token: cfg.Token}, nil
+	case "oauth2":
+		if err := validateHTTPURL(cfg.TokenURL); err != nil {
+			return nil, fmt.Errorf("auth.token_url: %w", err)
+		}
+		if cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, fmt.Errorf("oauth2 auth requires auth.client_id and auth.client_secret")
+		}
+		a := &oauth2Authenticator{
+			client:       client,
+			tokenURL:     cfg.TokenURL,
+			clientID:     cfg.ClientID,
+			clientSecret: cfg.ClientSecret,
+			scopes:       cfg.Scopes,
+		}
+		switch strings.ToLower(cfg.ClientAuth) {
+		case "", "header":
+		case "body":
+			a.authInBody = true
+		default:
+			return nil, fmt.Errorf("unknown auth.client_auth %q (expected header or body)", cfg.ClientAuth)
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("unknown auth.type %q (expected basic, bearer or oauth2)", cfg.Type)
+	}
+}
+
+func (a *oauth2Authenticator) authorize(ctx context.Context, req *http.Request) error {
+	token, err := a.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// invalidate drops the cached token, e.g. after the API rejected it.
+func (a *oauth2Authenticator) invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+// currentToken returns the cached token, requesting a new one when it is missing or about to expire.
+func (a *oauth2Authenticator) currentToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && (a.expiry.IsZero() || time.Now().Before(a.expiry)) {
+		return a.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.scopes) > 0 {
+		form.Set("scope", strings.Join(a.scopes, " "))
+	}
+	if a.authInBody {
+		form.Set("client_id", a.clientID)
+		form.Set("client_secret", a.clientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !a.authInBody {
+		req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oauth2 token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAssertionBodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("oauth2 token request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oauth2 token request failed with status %d: %s", resp.StatusCode, truncateForMessage(body))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("oauth2 token response has no access_token")
+	}
+	a.token = token.AccessToken
+	a.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		a.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - oauth2ExpiryMargin)
+	}
+	return a.token, nil
+}
@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPProbe_RequestCustomization(t *testing.T) {
+	var got struct {
+		method, query, contentType, userAgent, host, body, user, password string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got.method, got.query, got.body = r.Method, r.URL.RawQuery, string(body)
+		got.contentType, got.userAgent, got.host = r.Header.Get("Content-Type"), r.Header.Get("User-Agent"), r.Host
+		got.user, got.password, _ = r.BasicAuth()
+	}))
+	defer server.Close()
+
+	bodyFile := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(bodyFile, []byte(`{"ping": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	probe, err := NewProbeFromConfig(ProbeConfig{
+		Name:     "custom",
+		Type:     "http",
+		URL:      server.URL + "/search?lang=en",
+		Method:   "post",
+		Headers:  map[string]string{"content-type": "application/json", "User-Agent": "api-monitor", "Host": "api.example.com"},
+		BodyFile: bodyFile,
+		Query:    map[string]string{"q": "status page"},
+		Auth:     AuthConfig{Type: "basic", Username: "monitor", Password: "s3cret"},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	if result, err := probe.Execute(context.Background()); err != nil || result.Status != 1 {
+		t.Fatalf("Execute failed: status %d, err %v", result.Status, err)
+	}
+
+	if got.method != http.MethodPost || got.query != "lang=en&q=status+page" || got.body != `{"ping": true}` {
+		t.Errorf("unexpected request line or body: %+v", got)
+	}
+	if got.contentType != "application/json" || got.userAgent != "api-monitor" || got.host != "api.example.com" {
+		t.Errorf("unexpected headers: %+v", got)
+	}
+	if got.user != "monitor" || got.password != "s3cret" {
+		t.Errorf("unexpected basic auth %q/%q", got.user, got.password)
+	}
+}
+
+func TestHTTPProbe_OAuth2ClientCredentials(t *testing.T) {
+	var tokenRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "probe" || secret != "s3cret" || r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "read write" {
+			http.Error(w, `{"error": "invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		n := tokenRequests.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": fmt.Sprintf("token-%d", n), "expires_in": 3600})
+	})
+	var accepted atomic.Value
+	accepted.Store("token-1")
+	mux.HandleFunc("GET /api", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+accepted.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	probe, err := NewProbeFromConfig(ProbeConfig{
+		Name: "oauth",
+		Type: "http",
+		URL:  server.URL + "/api",
+		Auth: AuthConfig{Type: "oauth2", TokenURL: server.URL + "/token", ClientID: "probe", ClientSecret: "s3cret", Scopes: []string{"read", "write"}},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	run := func() int {
+		result, err := probe.Execute(context.Background())
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result.StatusCode
+	}
+
+	if run() != http.StatusOK || run() != http.StatusOK || tokenRequests.Load() != 1 {
+		t.Fatalf("expected the cached token to be reused, got %d token requests", tokenRequests.Load())
+	}
+
+	// A rejected token is dropped and replaced on the next run
+	accepted.Store("token-2")
+	if code := run(); code != http.StatusUnauthorized {
+		t.Fatalf("expected the stale token to be rejected, got %d", code)
+	}
+	if code := run(); code != http.StatusOK || tokenRequests.Load() != 2 {
+		t.Fatalf("expected a fresh token after a 401, got %d with %d token requests", code, tokenRequests.Load())
+	}
+}
+
+func TestNewRequestAuthenticator_Invalid(t *testing.T) {
+	tests := []struct {
+		cfg     AuthConfig
+		wantErr string
+	}{
+		{AuthConfig{Username: "monitor"}, "auth.type is required"},
+		{AuthConfig{Type: "basic"}, "basic auth requires auth.username"},
+		{AuthConfig{Type: "bearer"}, "bearer auth requires auth.token"},
+		{AuthConfig{Type: "oauth2", TokenURL: "https://idp.example.com/token", ClientID: "probe"}, "requires auth.client_id and auth.client_secret"},
+		{AuthConfig{Type: "digest"}, `unknown auth.type "digest"`},
+	}
+	for _, tt := range tests {
+		_, err := newRequestAuthenticator(tt.cfg, http.DefaultClient)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("newRequestAuthenticator(%+v) error = %v, want %q", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
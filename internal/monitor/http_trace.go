@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// HTTPTimings breaks the duration of an HTTP probe down into phases, in seconds.
+// Phases that did not happen (e.g. TLS for plain http) are zero.
+type HTTPTimings struct {
+	DNS      float64 // Name resolution
+	Connect  float64 // TCP connect
+	TLS      float64 // TLS handshake
+	TTFB     float64 // Request written to first response byte, i.e. server processing time
+	Transfer float64 // First response byte to end of body
+	RemoteIP string  // Address the request was sent to (the proxy when one is used)
+}
+
+// httpPhase is a named phase duration.
+type httpPhase struct {
+	name    string
+	seconds float64
+}
+
+// phases returns the timings in the order they occur, named as in the phase metric label.
+func (t *HTTPTimings) phases() []httpPhase {
+	return []httpPhase{
+		{"dns", t.DNS},
+		{"connect", t.Connect},
+		{"tls", t.TLS},
+		{"ttfb", t.TTFB},
+		{"transfer", t.Transfer},
+	}
+}
+
+// httpPhaseTracer records the phase boundaries of a single HTTP request via httptrace.
+// Callbacks can run concurrently when several addresses are dialed, hence the mutex.
+type httpPhaseTracer struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	remoteIP     string
+}
+
+// clientTrace returns the hooks to attach to the request context.
+func (t *httpPhaseTracer) clientTrace() *httptrace.ClientTrace {
+	now := func(field *time.Time) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*field = time.Now()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { now(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { now(&t.dnsDone) },
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				now(&t.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() { now(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { now(&t.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if host, _, err := net.SplitHostPort(info.Conn.RemoteAddr().String()); err == nil {
+				t.remoteIP = host
+			}
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { now(&t.wroteRequest) },
+		GotFirstResponseByte: func() { now(&t.firstByte) },
+	}
+}
+
+// timings computes the phase durations; bodyDone is when the response body was fully read.
+func (t *httpPhaseTracer) timings(bodyDone time.Time) *HTTPTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return &HTTPTimings{
+		DNS:      phaseSeconds(t.dnsStart, t.dnsDone),
+		Connect:  phaseSeconds(t.connectStart, t.connectDone),
+		TLS:      phaseSeconds(t.tlsStart, t.tlsDone),
+		TTFB:     phaseSeconds(t.wroteRequest, t.firstByte),
+		Transfer: phaseSeconds(t.firstByte, bodyDone),
+		RemoteIP: t.remoteIP,
+	}
+}
+
+// phaseSeconds returns the time between start and end, or 0 if the phase did not complete.
+func phaseSeconds(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Seconds()
+}
@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPProbe_PhaseTimings(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay) // Backend processing, shows up as ttfb
+		w.Write([]byte("first chunk\n"))
+		w.(http.Flusher).Flush()
+		time.Sleep(delay) // Slow body, shows up as transfer
+		w.Write([]byte("second chunk\n"))
+	}))
+	defer server.Close()
+
+	// Use a host name so the probe has to resolve it
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	probe := NewHTTPProbe(url, "trace-test")
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	probe.Client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{RootCAs: roots, ServerName: "example.com"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := probe.Execute(ctx)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	timings := result.HTTP
+	if timings == nil {
+		t.Fatal("expected phase timings on the result")
+	}
+	if timings.DNS <= 0 || timings.Connect <= 0 || timings.TLS <= 0 {
+		t.Errorf("expected dns, connect and tls phases to be measured: %+v", timings)
+	}
+	if timings.TTFB < delay.Seconds() || timings.Transfer < delay.Seconds() {
+		t.Errorf("expected ttfb and transfer of at least %s: %+v", delay, timings)
+	}
+	if timings.RemoteIP != "127.0.0.1" {
+		t.Errorf("remote IP = %q, want 127.0.0.1", timings.RemoteIP)
+	}
+
+	var names []string
+	for _, phase := range timings.phases() {
+		names = append(names, phase.name)
+	}
+	if got := strings.Join(names, ","); got != "dns,connect,tls,ttfb,transfer" {
+		t.Errorf("unexpected phase names %s", got)
+	}
+}
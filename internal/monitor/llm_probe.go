@@ -0,0 +1,277 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of "llm" probes, chosen to keep each check cheap.
+const (
+	defaultLLMPrompt    = "Reply with the single word: pong"
+	defaultLLMMaxTokens = 16
+)
+
+// llmRateLimitHeaders maps the rate limit resources reported by OpenAI-compatible APIs to
+// their limit and remaining headers.
+var llmRateLimitHeaders = map[string][2]string{
+	"requests": {"X-Ratelimit-Limit-Requests", "X-Ratelimit-Remaining-Requests"},
+	"tokens":   {"X-Ratelimit-Limit-Tokens", "X-Ratelimit-Remaining-Tokens"},
+}
+
+// LLMConfig defines the chat completion sent by an "llm" probe to an OpenAI-compatible
+// chat/completions url. Credentials are configured with the probe's auth block.
+type LLMConfig struct {
+	Model     string `yaml:"model"`
+	Prompt    string `yaml:"prompt"`     // Defaults to a one word reply request
+	MaxTokens int    `yaml:"max_tokens"` // Defaults to 16
+}
+
+// LLMResult carries the model specific outcome of an llm probe.
+type LLMResult struct {
+	TimeToFirstToken float64 // Seconds from sending the request to the first content token
+	TokensPerSecond  float64 // Completion tokens generated per second after the first token
+	PromptTokens     int     // From the usage report, 0 if the API did not send one
+	CompletionTokens int     // From the usage report, or the number of content chunks without one
+	RateLimits       []RateLimit
+}
+
+// RateLimit is the state of one rate limit reported in the response headers.
+type RateLimit struct {
+	Resource  string // requests or tokens
+	Limit     float64
+	Remaining float64
+}
+
+// LLMProbe is an implementation of ProbeExecutor that streams a small chat completion and
+// measures how quickly the model answers. Latency is the time until the stream completes.
+type LLMProbe struct {
+	Client         *http.Client
+	URL            string
+	Name           string
+	Headers        http.Header
+	ExpectedStatus *StatusMatcher
+	body           []byte
+	auth           requestAuthenticator
+}
+
+// llmStreamChunk is the subset of a chat.completion.chunk the probe reads.
+type llmStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// newLLMProbeFromConfig builds an LLMProbe for the "llm" probe type.
+func newLLMProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if err := validateHTTPURL(cfg.URL); err != nil {
+		return nil, err
+	}
+	lc := cfg.LLM
+	if lc.Model == "" {
+		return nil, fmt.Errorf("llm.model is required")
+	}
+	if lc.MaxTokens < 0 {
+		return nil, fmt.Errorf("llm.max_tokens must not be negative")
+	}
+	prompt, maxTokens := lc.Prompt, lc.MaxTokens
+	if prompt == "" {
+		prompt = defaultLLMPrompt
+	}
+	if maxTokens == 0 {
+		maxTokens = defaultLLMMaxTokens
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"model":          lc.Model,
+		"messages":       []map[string]string{{"role": "user", "content": prompt}},
+		"max_tokens":     maxTokens,
+		"stream":         true,
+		"stream_options": map[string]bool{"include_usage": true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := newProxyFunc(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newTLSClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	probe := &LLMProbe{
+		Client: &http.Client{
+			Transport: &http.Transport{Proxy: proxy, TLSClientConfig: tlsConfig, DisableKeepAlives: true},
+		},
+		URL:            cfg.URL,
+		Name:           cfg.Name,
+		Headers:        make(http.Header, len(cfg.Headers)),
+		ExpectedStatus: mustParseStatusMatcher([]string{"2xx"}),
+		body:           body,
+	}
+	for key, value := range cfg.Headers {
+		probe.Headers.Set(key, value)
+	}
+	if len(cfg.ExpectedStatus) > 0 {
+		if probe.ExpectedStatus, err = ParseStatusMatcher(cfg.ExpectedStatus); err != nil {
+			return nil, err
+		}
+	}
+	if probe.auth, err = newRequestAuthenticator(cfg.Auth, probe.Client); err != nil {
+		return nil, err
+	}
+	return probe, nil
+}
+
+// Execute implements the ProbeExecutor interface. API errors, an empty answer or a broken
+// stream are verdict failures; the rate limit headers are recorded whatever the status.
+func (p *LLMProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(p.body))
+	if err != nil {
+		return p.failure(0, err)
+	}
+	for key, values := range p.Headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if p.auth != nil {
+		if err := p.auth.authorize(ctx, req); err != nil {
+			return p.failure(0, err)
+		}
+	}
+
+	start := time.Now()
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return p.failure(time.Since(start).Seconds(), classifyTLSError(err))
+	}
+	defer resp.Body.Close()
+	if oauth, ok := p.auth.(*oauth2Authenticator); ok && resp.StatusCode == http.StatusUnauthorized {
+		oauth.invalidate()
+	}
+
+	llm := &LLMResult{RateLimits: parseRateLimits(resp.Header)}
+	if !p.ExpectedStatus.Match(resp.StatusCode) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxAssertionBodyBytes))
+		result := httpStatusResult(p.Name, p.ExpectedStatus, time.Since(start).Seconds(), resp.StatusCode)
+		result.Message += ": " + truncateForMessage(body)
+		result.LLM = llm
+		return result, nil
+	}
+
+	streamErr := p.readStream(resp.Body, start, llm)
+	latency := time.Since(start).Seconds()
+	result := NewProbeResult(p.Name, 1, latency, resp.StatusCode, nil)
+	result.LLM = llm
+	switch {
+	case streamErr != nil:
+		result.Status = 0
+		result.Message = streamErr.Error()
+	case llm.CompletionTokens == 0:
+		result.Status = 0
+		result.Message = "stream completed without any content"
+	default:
+		if generation := latency - llm.TimeToFirstToken; generation > 0 {
+			llm.TokensPerSecond = float64(llm.CompletionTokens) / generation
+		}
+	}
+	return result, nil
+}
+
+// failure returns the result of a run that failed with err. It carries an empty LLMResult so
+// the AI health series are updated as for a run that got an answer.
+func (p *LLMProbe) failure(latency float64, err error) (ProbeResult, error) {
+	result := NewProbeResult(p.Name, 0, latency, 0, err)
+	result.LLM = &LLMResult{}
+	return result, err
+}
+
+// readStream consumes the completion stream until [DONE], recording the time to the first
+// content token and the token usage in llm.
+func (p *LLMProbe) readStream(body io.Reader, start time.Time, llm *LLMResult) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 4096), maxSSELineBytes)
+
+	chunks := 0
+	usageReported := false
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			if !usageReported {
+				llm.CompletionTokens = chunks
+			}
+			return nil
+		}
+
+		var chunk llmStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("invalid stream chunk %q: %v", truncateForMessage([]byte(data)), err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("model error: %s", chunk.Error.Message)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if chunks == 0 {
+				llm.TimeToFirstToken = time.Since(start).Seconds()
+			}
+			chunks++
+		}
+		if chunk.Usage != nil {
+			usageReported = true
+			llm.PromptTokens = chunk.Usage.PromptTokens
+			llm.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return errors.New("stream timed out")
+		}
+		return fmt.Errorf("stream failed: %w", err)
+	}
+	return errors.New("stream closed before [DONE]")
+}
+
+// parseRateLimits reads the x-ratelimit-limit-* and x-ratelimit-remaining-* response headers.
+// Resources without both headers are left out.
+func parseRateLimits(header http.Header) []RateLimit {
+	var limits []RateLimit
+	for _, resource := range []string{"requests", "tokens"} {
+		names := llmRateLimitHeaders[resource]
+		limit, err := strconv.ParseFloat(header.Get(names[0]), 64)
+		if err != nil {
+			continue
+		}
+		remaining, err := strconv.ParseFloat(header.Get(names[1]), 64)
+		if err != nil {
+			continue
+		}
+		limits = append(limits, RateLimit{Resource: resource, Limit: limit, Remaining: remaining})
+	}
+	return limits
+}
@@ -0,0 +1,151 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// newTestLLMServer stubs an OpenAI-compatible chat/completions endpoint that streams one
+// chunk per word of reply, pausing firstTokenDelay before the first one.
+func newTestLLMServer(t *testing.T, reply string, firstTokenDelay time.Duration) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string `json:"model"`
+			Stream   bool   `json:"stream"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, `{"error": {"message": "invalid api key"}}`, http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "gpt-test" || !req.Stream || len(req.Messages) != 1 {
+			http.Error(w, `{"error": {"message": "bad request"}}`, http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("X-Ratelimit-Limit-Requests", "500")
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "499")
+		w.Header().Set("X-Ratelimit-Limit-Tokens", "30000")
+		w.Header().Set("X-Ratelimit-Remaining-Tokens", "29980")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		time.Sleep(firstTokenDelay)
+		words := strings.Fields(reply)
+		for _, word := range words {
+			fmt.Fprintf(w, "data: {\"choices\": [{\"delta\": {\"content\": %q}}]}\n\n", word)
+			w.(http.Flusher).Flush()
+			time.Sleep(5 * time.Millisecond)
+		}
+		fmt.Fprintf(w, "data: {\"choices\": [], \"usage\": {\"prompt_tokens\": 12, \"completion_tokens\": %d}}\n\n", len(words))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLLMProbe(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	server := newTestLLMServer(t, "pong pong pong pong", delay)
+
+	run := func(token string) ProbeResult {
+		probe, err := NewProbeFromConfig(ProbeConfig{
+			Name: "llm",
+			Type: "llm",
+			URL:  server.URL + "/v1/chat/completions",
+			Auth: AuthConfig{Type: "bearer", Token: token},
+			LLM:  LLMConfig{Model: "gpt-test"},
+		})
+		if err != nil {
+			t.Fatalf("NewProbeFromConfig failed: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := probe.Execute(ctx)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result
+	}
+
+	result := run("sk-test")
+	llm := result.LLM
+	if result.Status != 1 || llm == nil {
+		t.Fatalf("expected the probe to pass, got %d: %s", result.Status, result.Message)
+	}
+	if llm.TimeToFirstToken < delay.Seconds() || llm.TimeToFirstToken >= result.Latency {
+		t.Errorf("time to first token %.3fs, want at least %s and below the latency %.3fs", llm.TimeToFirstToken, delay, result.Latency)
+	}
+	if llm.PromptTokens != 12 || llm.CompletionTokens != 4 || llm.TokensPerSecond <= 0 {
+		t.Errorf("unexpected usage %+v", llm)
+	}
+	want := []RateLimit{{"requests", 500, 499}, {"tokens", 30000, 29980}}
+	if fmt.Sprint(llm.RateLimits) != fmt.Sprint(want) {
+		t.Errorf("rate limits = %+v, want %+v", llm.RateLimits, want)
+	}
+
+	result = run("sk-wrong")
+	if result.Status != 0 || result.StatusCode != http.StatusUnauthorized || !strings.Contains(result.Message, "invalid api key") {
+		t.Errorf("expected the API error to be reported, got %d: %q", result.Status, result.Message)
+	}
+}
+
+func TestLLMProbe_EmptyStream(t *testing.T) {
+	server := newTestLLMServer(t, "", 0)
+	probe, err := NewProbeFromConfig(ProbeConfig{
+		Name: "llm",
+		Type: "llm",
+		URL:  server.URL,
+		Auth: AuthConfig{Type: "bearer", Token: "sk-test"},
+		LLM:  LLMConfig{Model: "gpt-test"},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	result, err := probe.Execute(context.Background())
+	if err != nil || result.Status != 0 || result.Message != "stream completed without any content" {
+		t.Fatalf("expected an empty answer to fail, got %d: %q (%v)", result.Status, result.Message, err)
+	}
+
+	if _, err := NewProbeFromConfig(ProbeConfig{Name: "llm", Type: "llm", URL: server.URL}); err == nil {
+		t.Error("expected an error without llm.model")
+	}
+}
+
+func TestProbeAPI_ExportsFailedLLMProbe(t *testing.T) {
+	server := newTestLLMServer(t, "pong", 0)
+	probe, err := NewProbeFromConfig(ProbeConfig{
+		Name: "llm-down",
+		Type: "llm",
+		URL:  server.URL,
+		LLM:  LLMConfig{Model: "gpt-test"},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	server.Close()
+
+	probeAPI(context.Background(), probe, 3*time.Second, "test")
+	labels := prometheus.Labels{"api_name": "llm-down", "env": "test"}
+	defer AIHealthStatusGauge.Delete(labels)
+	defer AIHealthLatencyGauge.Delete(labels)
+	if got := testutil.ToFloat64(AIHealthStatusGauge.With(labels)); got != 0 {
+		t.Errorf("AI health status = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(AIHealthLatencyGauge.With(labels)); got != 3 {
+		t.Errorf("AI health latency = %v, want the timeout of 3s", got)
+	}
+}
@@ -23,6 +23,157 @@ var (
 		[]string{"api_name", "env"}, // Labels to distinguish different APIs and environments
 	)
 
+	// HTTPStatusCodeGauge records the last HTTP status code observed by a probe
+	HTTPStatusCodeGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_http_status_code",
+			Help: "Last HTTP status code returned to the API probe",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// HTTPPhaseLatencyGauge records how long each phase of an HTTP probe took
+	HTTPPhaseLatencyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_http_phase_seconds",
+			Help: "Duration of each HTTP probe phase (dns, connect, tls, ttfb, transfer) in seconds",
+		},
+		[]string{"api_name", "env", "phase"},
+	)
+
+	// HTTPRemoteIPGauge exposes the address an HTTP probe connected to as a label (value is always 1)
+	HTTPRemoteIPGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_http_remote_ip_info",
+			Help: "Remote IP address the HTTP probe last connected to (always 1)",
+		},
+		[]string{"api_name", "env", "ip"},
+	)
+
+	// DNSAnswerCountGauge records how many records of the queried type a dns probe received
+	DNSAnswerCountGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_dns_answer_count",
+			Help: "Number of answer records of the queried type returned to the DNS probe",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// TCPConnectLatencyGauge records how long a tcp probe took to establish its connection
+	TCPConnectLatencyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_tcp_connect_seconds",
+			Help: "Time taken by the TCP probe to establish its connection in seconds",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// GRPCHealthStatusGauge records the serving status returned by grpc.health.v1.Health/Check
+	GRPCHealthStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_grpc_health_status",
+			Help: "gRPC health serving status (0=UNKNOWN, 1=SERVING, 2=NOT_SERVING, 3=SERVICE_UNKNOWN)",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// ExecStateGauge records the Nagios state returned by exec probes
+	ExecStateGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_exec_state",
+			Help: "Exec probe Nagios state (0=OK, 1=WARNING, 2=CRITICAL, 3=UNKNOWN)",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// ExecPerfdataGauge records the performance data reported by exec probes
+	ExecPerfdataGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_exec_perfdata",
+			Help: "Exec probe performance data value, in the unit given by uom",
+		},
+		[]string{"api_name", "env", "label", "uom"},
+	)
+
+	// TLSVerificationFailedGauge flags probes whose last run was rejected by server certificate verification
+	TLSVerificationFailedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_tls_verification_failed",
+			Help: "Set to 1 while the probe target's certificate fails TLS verification, absent otherwise",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// TransactionStepStatusGauge records the outcome of each transaction step (1=passed, 0=failed or skipped)
+	TransactionStepStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_transaction_step_status",
+			Help: "Transaction probe step status (1 for passed, 0 for failed or skipped)",
+		},
+		[]string{"api_name", "env", "step"},
+	)
+
+	// TransactionStepLatencyGauge records the response time of each executed transaction step
+	TransactionStepLatencyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_transaction_step_seconds",
+			Help: "Transaction probe step response time in seconds",
+		},
+		[]string{"api_name", "env", "step"},
+	)
+
+	// AssertionStatusGauge records the outcome of each response body assertion (1=passed, 0=failed)
+	AssertionStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_assertion_status",
+			Help: "Response body assertion status (1 for passed, 0 for failed)",
+		},
+		[]string{"api_name", "env", "assertion"},
+	)
+
+	// ProbeLabelGauge exposes the static labels of configured probes (value is always 1)
+	ProbeLabelGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_probe_label",
+			Help: "Static labels attached to a configured API probe (always 1)",
+		},
+		[]string{"api_name", "env", "label", "value"},
+	)
+
+	// ConfigReloadSuccessGauge records whether the last configuration (re)load succeeded (1=success, 0=failure)
+	ConfigReloadSuccessGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "api_monitor_config_last_reload_successful",
+			Help: "Whether the last configuration reload attempt was successful (1 for success, 0 for failure)",
+		},
+	)
+
+	// ConfigReloadTimestampGauge records the Unix time of the last successful configuration (re)load
+	ConfigReloadTimestampGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "api_monitor_config_last_reload_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful configuration reload",
+		},
+	)
+
+	// ConfigReloadsTotal counts configuration (re)load attempts by result
+	ConfigReloadsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_monitor_config_reloads_total",
+			Help: "Total number of configuration reload attempts by result",
+		},
+		[]string{"result"},
+	)
+
+	// ConfigHashGauge exposes the hash of the active configuration as a label (value is always 1)
+	ConfigHashGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_monitor_config_hash",
+			Help: "SHA-256 of the active configuration (always 1)",
+		},
+		[]string{"hash"},
+	)
+
 	// CertificateTTLGauge records remaining time (in seconds) until TLS certificate expiry
 	CertificateTTLGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -32,6 +183,60 @@ var (
 		[]string{"api_name", "env"},
 	)
 
+	// CertificateCheckSuccessGauge records whether the last certificate check of a target succeeded
+	CertificateCheckSuccessGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_certificate_check_success",
+			Help: "Whether the last certificate check of the target could read its certificate (1=yes, 0=no)",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// CertificateChainTTLGauge records the remaining time until the first certificate of the served chain expires
+	CertificateChainTTLGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_certificate_chain_ttl_seconds",
+			Help: "Remaining time in seconds until the earliest expiring certificate of the served chain (leaf or intermediate) expires",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// CertificateInfoGauge describes each certificate of the served chain, position 0 being the leaf
+	CertificateInfoGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_certificate_info",
+			Help: "Certificates of the served chain, always 1 (position 0 is the leaf)",
+		},
+		[]string{"api_name", "env", "position", "subject", "issuer", "serial", "key_type", "signature_algorithm"},
+	)
+
+	// CertificateRevocationStatusGauge records the revocation status of the leaf certificate
+	CertificateRevocationStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_certificate_revocation_status",
+			Help: "Revocation status of the leaf certificate (0=good, 1=revoked, 2=unknown); method is ocsp_stapled, ocsp, crl or none",
+		},
+		[]string{"api_name", "env", "method"},
+	)
+
+	// CertificateOCSPAgeGauge records how long ago the OCSP response for the leaf was produced
+	CertificateOCSPAgeGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_certificate_ocsp_age_seconds",
+			Help: "Time in seconds since the thisUpdate of the OCSP response (stapled or fetched) for the leaf certificate",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// CertificateOCSPNextUpdateGauge records the remaining validity of the OCSP response for the leaf
+	CertificateOCSPNextUpdateGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_certificate_ocsp_next_update_seconds",
+			Help: "Time in seconds until the nextUpdate of the OCSP response for the leaf certificate, negative once stale",
+		},
+		[]string{"api_name", "env"},
+	)
+
 	// DirectConnectBPSInGauge records AWS Direct Connect inbound traffic in bits per second
 	DirectConnectBPSInGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -202,13 +407,81 @@ var (
 		},
 		[]string{"api_name", "env"},
 	)
+
+	// AIHealthTTFTGauge records the time llm probes waited for the first token
+	AIHealthTTFTGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_ai_health_ttft_seconds",
+			Help: "LLM probe time to first token in seconds",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// AIHealthTokensPerSecondGauge records the generation throughput of llm probes
+	AIHealthTokensPerSecondGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_ai_health_tokens_per_second",
+			Help: "LLM probe completion tokens generated per second after the first token",
+		},
+		[]string{"api_name", "env"},
+	)
+
+	// AIHealthUsageTokensGauge records the token usage reported for the last llm probe request
+	AIHealthUsageTokensGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_ai_health_usage_tokens",
+			Help: "LLM probe tokens used by the last request (type is prompt or completion)",
+		},
+		[]string{"api_name", "env", "type"},
+	)
+
+	// AIHealthRateLimitGauge records the rate limits reported by the LLM API
+	AIHealthRateLimitGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_ai_health_ratelimit_limit",
+			Help: "LLM API rate limit from the x-ratelimit-limit-* headers (resource is requests or tokens)",
+		},
+		[]string{"api_name", "env", "resource"},
+	)
+
+	// AIHealthRateLimitRemainingGauge records the remaining rate limit reported by the LLM API
+	AIHealthRateLimitRemainingGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "api_ai_health_ratelimit_remaining",
+			Help: "LLM API rate limit left from the x-ratelimit-remaining-* headers (resource is requests or tokens)",
+		},
+		[]string{"api_name", "env", "resource"},
+	)
 )
 
 // RegisterMetrics registers Prometheus metrics.
 func RegisterMetrics() {
 	prometheus.MustRegister(APIStatusGauge)
 	prometheus.MustRegister(APILatencyGauge)
+	prometheus.MustRegister(HTTPStatusCodeGauge)
+	prometheus.MustRegister(HTTPPhaseLatencyGauge)
+	prometheus.MustRegister(HTTPRemoteIPGauge)
+	prometheus.MustRegister(DNSAnswerCountGauge)
+	prometheus.MustRegister(TCPConnectLatencyGauge)
+	prometheus.MustRegister(GRPCHealthStatusGauge)
+	prometheus.MustRegister(TLSVerificationFailedGauge)
+	prometheus.MustRegister(ExecStateGauge)
+	prometheus.MustRegister(ExecPerfdataGauge)
+	prometheus.MustRegister(TransactionStepStatusGauge)
+	prometheus.MustRegister(TransactionStepLatencyGauge)
+	prometheus.MustRegister(AssertionStatusGauge)
+	prometheus.MustRegister(ProbeLabelGauge)
+	prometheus.MustRegister(ConfigReloadSuccessGauge)
+	prometheus.MustRegister(ConfigReloadTimestampGauge)
+	prometheus.MustRegister(ConfigReloadsTotal)
+	prometheus.MustRegister(ConfigHashGauge)
 	prometheus.MustRegister(CertificateTTLGauge)
+	prometheus.MustRegister(CertificateCheckSuccessGauge)
+	prometheus.MustRegister(CertificateChainTTLGauge)
+	prometheus.MustRegister(CertificateInfoGauge)
+	prometheus.MustRegister(CertificateRevocationStatusGauge)
+	prometheus.MustRegister(CertificateOCSPAgeGauge)
+	prometheus.MustRegister(CertificateOCSPNextUpdateGauge)
 	prometheus.MustRegister(DirectConnectBPSInGauge)
 	prometheus.MustRegister(DirectConnectBPSOutGauge)
 	prometheus.MustRegister(DirectConnectPPSInGauge)
@@ -228,4 +501,9 @@ func RegisterMetrics() {
 	prometheus.MustRegister(DXAPILatencyGauge)
 	prometheus.MustRegister(AIHealthStatusGauge)
 	prometheus.MustRegister(AIHealthLatencyGauge)
+	prometheus.MustRegister(AIHealthTTFTGauge)
+	prometheus.MustRegister(AIHealthTokensPerSecondGauge)
+	prometheus.MustRegister(AIHealthUsageTokensGauge)
+	prometheus.MustRegister(AIHealthRateLimitGauge)
+	prometheus.MustRegister(AIHealthRateLimitRemainingGauge)
 }
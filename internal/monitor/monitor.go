@@ -2,9 +2,9 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"log" // log is kept only for http.ListenAndServe's Fatal
 	"net/http"
-	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -12,11 +12,17 @@ import (
 )
 
 // probeAPI performs the probing for a single API.
-func probeAPI(executor ProbeExecutor, apiTimeout time.Duration, currentEnv string) {
-	ctx, cancel := context.WithTimeout(context.Background(), apiTimeout)
+// Results are dropped if parent is cancelled while the probe is in flight, so a
+// stopped probe does not re-create series that were just removed.
+func probeAPI(parent context.Context, executor ProbeExecutor, apiTimeout time.Duration, currentEnv string) {
+	ctx, cancel := context.WithTimeout(parent, apiTimeout)
 	defer cancel()
 
 	probeResult, err := executor.Execute(ctx)
+	if parent.Err() != nil {
+		return
+	}
+	exportTLSVerification(probeResult.APIName, currentEnv, err, probeResult.Error)
 
 	if err != nil {
 		FmtLog(LogLevelError, "  -> FAILED, error: %v", err)
@@ -26,69 +32,159 @@ func probeAPI(executor ProbeExecutor, apiTimeout time.Duration, currentEnv strin
 		APILatencyGauge.With(
 			prometheus.Labels{"api_name": probeResult.APIName, "env": currentEnv}).
 			Set(apiTimeout.Seconds()) // Record timeout duration on failure
+		if probeResult.LLM != nil {
+			probeResult.Status, probeResult.Latency = 0, apiTimeout.Seconds()
+			exportLLMResult(probeResult, currentEnv)
+		}
 		return
 	}
 
-	FmtLog(LogLevelInfo, "  -> SUCCESS (TLS connected), response time: %.2fs, status code: %d", probeResult.Latency, probeResult.StatusCode)
+	if probeResult.Status == 1 {
+		FmtLog(LogLevelInfo, "  -> SUCCESS, %s response time: %.2fs, status code: %d", probeResult.APIName, probeResult.Latency, probeResult.StatusCode)
+	} else {
+		FmtLog(LogLevelWarn, "  -> FAILED, %s response time: %.2fs, status code: %d: %s", probeResult.APIName, probeResult.Latency, probeResult.StatusCode, probeResult.Message)
+	}
 	APIStatusGauge.With(
 		prometheus.Labels{"api_name": probeResult.APIName, "env": currentEnv}).
-		Set(1)
+		Set(float64(probeResult.Status))
 	APILatencyGauge.With(
 		prometheus.Labels{"api_name": probeResult.APIName, "env": currentEnv}).
 		Set(probeResult.Latency)
+	switch {
+	case probeResult.GRPC != nil:
+		GRPCHealthStatusGauge.With(
+			prometheus.Labels{"api_name": probeResult.APIName, "env": currentEnv}).
+			Set(float64(probeResult.StatusCode))
+	case probeResult.Exec != nil:
+		exportExecResult(probeResult.APIName, currentEnv, probeResult.Exec)
+	case probeResult.StatusCode > 0:
+		HTTPStatusCodeGauge.With(
+			prometheus.Labels{"api_name": probeResult.APIName, "env": currentEnv}).
+			Set(float64(probeResult.StatusCode))
+	}
+	if probeResult.HTTP != nil {
+		exportHTTPTimings(probeResult.APIName, currentEnv, probeResult.HTTP)
+	}
+	if probeResult.LLM != nil {
+		exportLLMResult(probeResult, currentEnv)
+	}
+	if probeResult.DNS != nil {
+		DNSAnswerCountGauge.With(
+			prometheus.Labels{"api_name": probeResult.APIName, "env": currentEnv}).
+			Set(float64(len(probeResult.DNS.Answers)))
+	}
+	if probeResult.TCP != nil {
+		TCPConnectLatencyGauge.With(
+			prometheus.Labels{"api_name": probeResult.APIName, "env": currentEnv}).
+			Set(probeResult.TCP.ConnectLatency)
+	}
+	for _, step := range probeResult.Steps {
+		TransactionStepStatusGauge.WithLabelValues(probeResult.APIName, currentEnv, step.Name).Set(float64(step.Status))
+		if !step.Skipped {
+			TransactionStepLatencyGauge.WithLabelValues(probeResult.APIName, currentEnv, step.Name).Set(step.Latency)
+		}
+	}
+	for _, a := range probeResult.Assertions {
+		passed := 0.0
+		if a.Passed {
+			passed = 1
+		}
+		AssertionStatusGauge.WithLabelValues(probeResult.APIName, currentEnv, a.Name).Set(passed)
+	}
 }
 
-// probeSingleAPI is a helper function to probe a single API in a goroutine.
-func probeSingleAPI(executor ProbeExecutor, apiTimeout time.Duration, currentEnv string, wg *sync.WaitGroup) {
-	defer wg.Done()
-	probeAPI(executor, apiTimeout, currentEnv)
+// exportExecResult exports the state and performance data of an exec probe. Perfdata series
+// that the last run no longer reported are removed.
+func exportExecResult(apiName, currentEnv string, result *ExecResult) {
+	labels := prometheus.Labels{"api_name": apiName, "env": currentEnv}
+	ExecStateGauge.With(labels).Set(float64(result.State))
+	ExecPerfdataGauge.DeletePartialMatch(labels)
+	for _, p := range result.Perfdata {
+		ExecPerfdataGauge.WithLabelValues(apiName, currentEnv, p.Label, p.UOM).Set(p.Value)
+	}
 }
 
+// exportLLMResult exports an llm probe next to the built-in AI health check metrics.
+// Throughput and time to first token are only updated when the model answered.
+func exportLLMResult(result ProbeResult, currentEnv string) {
+	labels := prometheus.Labels{"api_name": result.APIName, "env": currentEnv}
+	llm := result.LLM
+	AIHealthStatusGauge.With(labels).Set(float64(result.Status))
+	AIHealthLatencyGauge.With(labels).Set(result.Latency)
+	if result.Status == 1 {
+		AIHealthTTFTGauge.With(labels).Set(llm.TimeToFirstToken)
+		AIHealthTokensPerSecondGauge.With(labels).Set(llm.TokensPerSecond)
+		AIHealthUsageTokensGauge.WithLabelValues(result.APIName, currentEnv, "prompt").Set(float64(llm.PromptTokens))
+		AIHealthUsageTokensGauge.WithLabelValues(result.APIName, currentEnv, "completion").Set(float64(llm.CompletionTokens))
+	}
+	for _, limit := range llm.RateLimits {
+		AIHealthRateLimitGauge.WithLabelValues(result.APIName, currentEnv, limit.Resource).Set(limit.Limit)
+		AIHealthRateLimitRemainingGauge.WithLabelValues(result.APIName, currentEnv, limit.Resource).Set(limit.Remaining)
+	}
+}
+
+// exportTLSVerification flags the probe when one of errs is a certificate verification failure
+// and clears the flag otherwise.
+func exportTLSVerification(apiName, currentEnv string, errs ...error) {
+	labels := prometheus.Labels{"api_name": apiName, "env": currentEnv}
+	for _, err := range errs {
+		if errors.As(err, new(*TLSVerificationError)) {
+			TLSVerificationFailedGauge.With(labels).Set(1)
+			return
+		}
+	}
+	TLSVerificationFailedGauge.Delete(labels)
+}
+
+// exportHTTPTimings exports the phase breakdown and remote address of an HTTP probe.
+func exportHTTPTimings(apiName, currentEnv string, timings *HTTPTimings) {
+	for _, phase := range timings.phases() {
+		HTTPPhaseLatencyGauge.WithLabelValues(apiName, currentEnv, phase.name).Set(phase.seconds)
+	}
+	if timings.RemoteIP != "" {
+		// Only the current address is kept, e.g. after a DNS change
+		HTTPRemoteIPGauge.DeletePartialMatch(prometheus.Labels{"api_name": apiName, "env": currentEnv})
+		HTTPRemoteIPGauge.WithLabelValues(apiName, currentEnv, timings.RemoteIP).Set(1)
+	}
+}
 
+// runProbeLoop probes a single configured API on its own interval until ctx is cancelled.
+func runProbeLoop(ctx context.Context, probe *scheduledProbe, currentEnv string) {
+	for {
+		probeAPI(ctx, probe.executor, probe.timeout, currentEnv)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(probe.interval):
+		}
+	}
+}
+
+// exportProbeLabels exposes the static labels of a configured probe as an info metric.
+func exportProbeLabels(cfg ProbeConfig, currentEnv string) {
+	for key, value := range cfg.Labels {
+		ProbeLabelGauge.WithLabelValues(cfg.Name, currentEnv, key, value).Set(1)
+	}
+}
 
 // StartMonitoring starts the API monitoring service.
-func StartMonitoring(
-	apiTimeout,
-	apiProbeInterval time.Duration,
-	currentEnv string,
-	metricsPort string,
-	awsConfig AWSConfig) {
+// configPath and envOverride are kept so the configuration can be reloaded on SIGHUP or file change.
+func StartMonitoring(settings *Settings, configPath, envOverride string) {
 	// Register Prometheus metrics
 	RegisterMetrics()
 
-	// Hardcode API probes
-	// Define API probes.
-	apiProbes := []ProbeExecutor{
-		NewBaiduHTTPSProbe(), // Baidu HTTPS GET probe
-		NewLalaHTTPSProbe(),  // Lala HTTPS GET probe
-		NewIPHTTPProbe(),     // IP HTTP GET probe
-	}
-
-	// Start a goroutine to periodically probe all resources
-	go func() {
-		for {
-			var wg sync.WaitGroup
-			// Process API probes (generate API metrics)
-			for _, probe := range apiProbes {
-				wg.Add(1)
-				go probeSingleAPI(probe, apiTimeout, currentEnv, &wg)
-			}
-			wg.Wait() // Wait for all API probes to complete
-
-			FmtLog(LogLevelInfo, "API probes completed, waiting for %v before next run...", apiProbeInterval)
-			time.Sleep(apiProbeInterval)
-		}
-	}()
-
-	// Start Direct Connect monitoring in its own dedicated goroutine
-	// This encapsulates DX probe creation and execution logic
-	StartDirectConnectMonitoring(awsConfig, apiTimeout, apiProbeInterval, currentEnv)
+	// Start API, Direct Connect and AI health check loops, each in its own goroutine
+	supervisor := newProbeSupervisor()
+	supervisor.apply(settings, false)
+	recordConfigReload(true, settings.Hash)
 
-	// Start AI health check monitoring in its own dedicated goroutine
-	StartAIMonitoring(apiTimeout, apiProbeInterval, currentEnv)
+	// Watch the configuration for changes and apply them without a restart
+	reloader := newConfigReloader(configPath, envOverride, supervisor)
+	go reloader.watch()
 
 	// Start an HTTP server to expose metrics
 	http.Handle("/metrics", promhttp.Handler())
-	FmtLog(LogLevelInfo, "Prometheus metrics server started on http://localhost%s", metricsPort)
-	log.Fatal(http.ListenAndServe(metricsPort, nil))
+	http.HandleFunc("/config", supervisor.serveConfig) // Active configuration, secrets redacted
+	FmtLog(LogLevelInfo, "Prometheus metrics server started on http://localhost%s", settings.MetricsPort)
+	log.Fatal(http.ListenAndServe(settings.MetricsPort, nil))
 }
@@ -1,9 +1,13 @@
 package monitor
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls" // Added for InvalidURLProbe
+	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptrace"
 	"time"
 )
 
@@ -13,91 +17,110 @@ type ProbeExecutor interface {
 }
 
 
-// TcpProbe is an implementation of ProbeExecutor for executing HTTPS GET requests.
-type TcpProbe struct {
-	Client *http.Client
-	URL    string
-	Name   string
+// HTTPProbe is an implementation of ProbeExecutor for executing HTTP(S) requests.
+type HTTPProbe struct {
+	Client         *http.Client
+	URL            string
+	Name           string
+	Method         string         // HTTP method, GET if empty
+	Headers        http.Header    // Extra request headers, a Host header overrides the request host
+	Body           []byte         // Request body, none if nil
+	ExpectedStatus *StatusMatcher // Accepted status codes, 2xx/3xx if nil
+	assertions     []*bodyAssertion
+	auth           requestAuthenticator
 }
 
-// NewTcpProbe creates and returns a new HTTPSProbe instance.
-func NewTcpProbe(url, name string) *TcpProbe {
-	return &TcpProbe{
+// NewHTTPProbe creates and returns a new HTTPProbe instance.
+func NewHTTPProbe(url, name string) *HTTPProbe {
+	return &HTTPProbe{
 		Client: &http.Client{
 			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+				TLSClientConfig: &tls.Config{},
+				// Every probe opens a new connection so DNS, connect and TLS are measured each time
+				DisableKeepAlives: true,
 			},
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				return http.ErrUseLastResponse
 			},
 		},
-		URL:    url,
-		Name:   name,
+		URL:            url,
+		Name:           name,
+		Method:         http.MethodGet,
+		ExpectedStatus: mustParseStatusMatcher(defaultHTTPStatusRules),
 	}
 }
 
-// Execute implements the ProbeExecutor interface, performing an HTTPS GET request.
-func (p *TcpProbe) Execute(ctx context.Context) (ProbeResult, error) {
-	start := time.Now() // Start latency measurement here
-	req, err := http.NewRequestWithContext(ctx, "GET", p.URL, nil)
+// Execute implements the ProbeExecutor interface, performing an HTTP(S) request.
+func (p *HTTPProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	method := p.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	tracer := &httpPhaseTracer{}
+	var body io.Reader
+	if p.Body != nil {
+		body = bytes.NewReader(p.Body)
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, tracer.clientTrace()), method, p.URL, body)
 	if err != nil {
 		return NewProbeResult(p.Name, 0, 0, 0, err), err
 	}
+	for key, values := range p.Headers {
+		req.Header[key] = values
+	}
+	if host := p.Headers.Get("Host"); host != "" {
+		req.Host = host
+	}
+	// Credentials are obtained outside of the trace so token requests do not skew the phase timings
+	if p.auth != nil {
+		if err := p.auth.authorize(ctx, req); err != nil {
+			return NewProbeResult(p.Name, 0, 0, 0, err), err
+		}
+	}
 
+	start := time.Now() // Start latency measurement here
 	resp, err := p.Client.Do(req)
 	latency := time.Since(start).Seconds() // Calculate latency here
 	if err != nil {
+		err = classifyTLSError(err)
 		return NewProbeResult(p.Name, 0, latency, 0, err), err
 	}
 	defer resp.Body.Close()
-
-	return NewProbeResult(p.Name, 1, latency, resp.StatusCode, nil), nil
-}
-
-// BaiduHTTPSProbe is an implementation of ProbeExecutor specifically for HTTPS GET requests to Baidu.
-type BaiduHTTPSProbe struct {
-	TcpProbe // Embed HTTPSProbe
-}
-
-// NewBaiduHTTPSProbe creates and returns a new BaiduHTTPSProbe instance.
-func NewBaiduHTTPSProbe() *BaiduHTTPSProbe {
-	// Default URL and Name for Baidu HTTPS probe
-	baiduURL := "https://www.baidu.com"
-	baiduName := "BaiduHTTPSGetProbe"
-
-	return &BaiduHTTPSProbe{
-		TcpProbe: *NewTcpProbe(baiduURL, baiduName),
+	// A rejected OAuth2 token is dropped so the next run requests a fresh one
+	if oauth, ok := p.auth.(*oauth2Authenticator); ok && resp.StatusCode == http.StatusUnauthorized {
+		oauth.invalidate()
 	}
-}
-
-// LalaHTTPSProbe is an implementation of ProbeExecutor specifically for HTTPS GET requests to lala.com.
-type LalaHTTPSProbe struct {
-	TcpProbe // Embed HTTPSProbe
-}
-
-// NewLalaHTTPSProbe creates and returns a new LalaHTTPSProbe instance.
-func NewLalaHTTPSProbe() *LalaHTTPSProbe {
-	// Default URL and Name for Lala HTTPS probe
-	lalaURL := "https://www.lala.com"
-	lalaName := "lalahttpsgetprobe"
 
-	return &LalaHTTPSProbe{
-		TcpProbe: *NewTcpProbe(lalaURL, lalaName),
+	// The body is read up to maxAssertionBodyBytes to time the transfer; it is only kept
+	// when there is something to check in it
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxAssertionBodyBytes))
+	timings := tracer.timings(time.Now())
+	if err != nil {
+		err = fmt.Errorf("failed to read response body: %w", err)
+		return NewProbeResult(p.Name, 0, latency, resp.StatusCode, err), err
+	}
+	var assertionResults []AssertionResult
+	if len(p.assertions) > 0 {
+		assertionResults = evaluateAssertions(p.assertions, respBody)
 	}
-}
 
-// IPHTTPProbe is an implementation of ProbeExecutor specifically for HTTP GET requests to a specific IP.
-type IPHTTPProbe struct {
-	TcpProbe // Embed HTTPProbe
+	result := httpStatusResult(p.Name, p.ExpectedStatus, latency, resp.StatusCode)
+	result.HTTP = timings
+	applyAssertionResults(&result, assertionResults)
+	return result, nil
 }
 
-// NewIPHTTPProbe creates and returns a new IPHTTPProbe instance.
-func NewIPHTTPProbe() *IPHTTPProbe {
-	// Default URL and Name for IP HTTP probe
-	ipURL := "http://180.101.51.73"
-	ipName := "iphttpgetprobe"
-
-	return &IPHTTPProbe{
-		TcpProbe: *NewTcpProbe(ipURL, ipName),
+// httpStatusResult builds the result for a completed HTTP exchange: Status is 1 when the
+// status code is accepted by expected (2xx/3xx if nil) and 0 otherwise.
+func httpStatusResult(name string, expected *StatusMatcher, latency float64, statusCode int) ProbeResult {
+	if expected == nil {
+		expected = mustParseStatusMatcher(defaultHTTPStatusRules)
+	}
+	if expected.Match(statusCode) {
+		return NewProbeResult(name, 1, latency, statusCode, nil)
 	}
-}
\ No newline at end of file
+	result := NewProbeResult(name, 0, latency, statusCode, nil)
+	result.Message = fmt.Sprintf("status code %d not accepted by expected_status %s", statusCode, expected)
+	return result
+}
@@ -0,0 +1,251 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProbeFactory builds a ProbeExecutor from its declarative configuration.
+type ProbeFactory func(cfg ProbeConfig) (ProbeExecutor, error)
+
+var (
+	probeFactoriesMu sync.RWMutex
+	probeFactories   = map[string]ProbeFactory{}
+)
+
+// init registers the built-in probe types.
+func init() {
+	RegisterProbeType("http", newHTTPProbeFromConfig)
+	RegisterProbeType("dns", newDNSProbeFromConfig)
+	RegisterProbeType("tcp", newTCPProbeFromConfig)
+	RegisterProbeType("grpc", newGRPCProbeFromConfig)
+	RegisterProbeType("websocket", newWebSocketProbeFromConfig)
+	RegisterProbeType("sse", newSSEProbeFromConfig)
+	RegisterProbeType("transaction", newTransactionProbeFromConfig)
+	RegisterProbeType("exec", newExecProbeFromConfig)
+	RegisterProbeType("llm", newLLMProbeFromConfig)
+}
+
+// RegisterProbeType makes a probe type available to the "type" field of probe definitions.
+// Registering the same type twice replaces the previous factory.
+func RegisterProbeType(probeType string, factory ProbeFactory) {
+	probeFactoriesMu.Lock()
+	defer probeFactoriesMu.Unlock()
+	probeFactories[strings.ToLower(probeType)] = factory
+}
+
+// RegisteredProbeTypes returns the sorted list of known probe types.
+func RegisteredProbeTypes() []string {
+	probeFactoriesMu.RLock()
+	defer probeFactoriesMu.RUnlock()
+	types := make([]string, 0, len(probeFactories))
+	for t := range probeFactories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewProbeFromConfig looks up the factory for cfg.Type and builds the probe.
+func NewProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("probe name is required")
+	}
+	probeFactoriesMu.RLock()
+	factory, ok := probeFactories[strings.ToLower(cfg.Type)]
+	probeFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("probe %s: unknown type %q (known types: %s)",
+			cfg.Name, cfg.Type, strings.Join(RegisteredProbeTypes(), ", "))
+	}
+	probe, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("probe %s: %w", cfg.Name, err)
+	}
+	return probe, nil
+}
+
+// newHTTPProbeFromConfig builds an HTTPProbe for the "http" probe type.
+func newHTTPProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if err := validateHTTPURL(cfg.URL); err != nil {
+		return nil, err
+	}
+	requestURL, err := addQueryParams(cfg.URL, cfg.Query)
+	if err != nil {
+		return nil, err
+	}
+	probe := NewHTTPProbe(requestURL, cfg.Name)
+	if cfg.Method != "" {
+		if !httpMethodPattern.MatchString(cfg.Method) {
+			return nil, fmt.Errorf("invalid HTTP method %q", cfg.Method)
+		}
+		probe.Method = strings.ToUpper(cfg.Method)
+	}
+	if len(cfg.ExpectedStatus) > 0 {
+		matcher, err := ParseStatusMatcher(cfg.ExpectedStatus)
+		if err != nil {
+			return nil, err
+		}
+		probe.ExpectedStatus = matcher
+	}
+	assertions, err := compileAssertions(cfg.Assertions)
+	if err != nil {
+		return nil, err
+	}
+	probe.assertions = assertions
+	proxy, err := newProxyFunc(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport := probe.Client.Transport.(*http.Transport)
+	transport.Proxy = proxy
+	if transport.TLSClientConfig, err = newTLSClientConfig(cfg.TLS); err != nil {
+		return nil, err
+	}
+	if len(cfg.Headers) > 0 {
+		probe.Headers = make(http.Header, len(cfg.Headers))
+		for key, value := range cfg.Headers {
+			probe.Headers.Set(key, value)
+		}
+	}
+	if probe.Body, err = loadRequestBody(cfg.Body, cfg.BodyFile); err != nil {
+		return nil, err
+	}
+	if probe.auth, err = newRequestAuthenticator(cfg.Auth, probe.Client); err != nil {
+		return nil, err
+	}
+	return probe, nil
+}
+
+// addQueryParams adds params to the query string of rawURL, keeping any parameters already in it.
+func addQueryParams(rawURL string, params map[string]string) (string, error) {
+	if len(params) == 0 {
+		return rawURL, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+	query := u.Query()
+	for key, value := range params {
+		query.Add(key, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// loadRequestBody returns the inline body or the contents of bodyFile. It returns nil when neither is set.
+func loadRequestBody(body, bodyFile string) ([]byte, error) {
+	switch {
+	case body != "" && bodyFile != "":
+		return nil, fmt.Errorf("body and body_file are mutually exclusive")
+	case bodyFile != "":
+		data, err := os.ReadFile(bodyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read body_file: %w", err)
+		}
+		return data, nil
+	case body != "":
+		return []byte(body), nil
+	}
+	return nil, nil
+}
+
+// httpMethodPattern matches an HTTP method token such as GET or PROPFIND.
+var httpMethodPattern = regexp.MustCompile(`^[A-Za-z]+$`)
+
+// validateHTTPURL checks that rawURL is an absolute http:// or https:// URL.
+func validateHTTPURL(rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("url is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url %q: scheme must be http or https", rawURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid url %q: missing host", rawURL)
+	}
+	return nil
+}
+
+// scheduledProbe couples a probe executor with its effective schedule.
+type scheduledProbe struct {
+	config   ProbeConfig
+	executor ProbeExecutor
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// resolveProbeDuration parses an optional per-probe duration, falling back to the global default.
+func resolveProbeDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive, got %s", value)
+	}
+	return d, nil
+}
+
+// newScheduledProbe builds the executor for cfg and resolves its interval and timeout.
+func newScheduledProbe(cfg ProbeConfig, defaultTimeout, defaultInterval time.Duration) (*scheduledProbe, error) {
+	timeout, err := resolveProbeDuration(cfg.Timeout, defaultTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("probe %s: invalid timeout: %w", cfg.Name, err)
+	}
+	interval, err := resolveProbeDuration(cfg.Interval, defaultInterval)
+	if err != nil {
+		return nil, fmt.Errorf("probe %s: invalid interval: %w", cfg.Name, err)
+	}
+	executor, err := NewProbeFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledProbe{
+		config:   cfg,
+		executor: executor,
+		interval: interval,
+		timeout:  timeout,
+	}, nil
+}
+
+// createConfiguredProbes creates probes from the probes section of the configuration.
+// Valid probes are always returned; problems with individual definitions are joined into the error
+// so callers can decide whether to run with the remaining probes or reject the configuration.
+func createConfiguredProbes(cfgs []ProbeConfig, defaultTimeout, defaultInterval time.Duration) ([]*scheduledProbe, error) {
+	var probes []*scheduledProbe
+	var errs []error
+	seen := make(map[string]bool, len(cfgs))
+
+	for _, cfg := range cfgs {
+		if seen[cfg.Name] {
+			errs = append(errs, fmt.Errorf("duplicate probe name %q", cfg.Name))
+			continue
+		}
+		probe, err := newScheduledProbe(cfg, defaultTimeout, defaultInterval)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		seen[cfg.Name] = true
+		probes = append(probes, probe)
+	}
+
+	return probes, errors.Join(errs...)
+}
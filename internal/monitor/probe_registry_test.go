@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProbe is a ProbeExecutor built by the test probe type.
+type fakeProbe struct {
+	config ProbeConfig
+}
+
+func (p *fakeProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	return NewProbeResult(p.config.Name, 1, 0, 0, nil), nil
+}
+
+// registerTestProbeType registers factory as probeType until the test ends.
+func registerTestProbeType(t *testing.T, probeType string, factory ProbeFactory) {
+	t.Helper()
+	RegisterProbeType(probeType, factory)
+	t.Cleanup(func() {
+		probeFactoriesMu.Lock()
+		defer probeFactoriesMu.Unlock()
+		delete(probeFactories, strings.ToLower(probeType))
+	})
+}
+
+func TestRegisterProbeType(t *testing.T) {
+	registerTestProbeType(t, "Fake-Test", func(cfg ProbeConfig) (ProbeExecutor, error) {
+		return &fakeProbe{config: cfg}, nil
+	})
+	if !slices.Contains(RegisteredProbeTypes(), "fake-test") {
+		t.Fatalf("registered type missing from %v", RegisteredProbeTypes())
+	}
+	if !slices.IsSorted(RegisteredProbeTypes()) {
+		t.Fatalf("registered types are not sorted: %v", RegisteredProbeTypes())
+	}
+
+	// Types are matched case-insensitively and the factory receives the whole definition
+	probe, err := NewProbeFromConfig(ProbeConfig{Name: "fake", Type: "FAKE-test", URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	if fake, ok := probe.(*fakeProbe); !ok || fake.config.URL != "https://example.com" {
+		t.Fatalf("expected the registered factory to build the probe, got %#v", probe)
+	}
+
+	// Registering the type again replaces the factory; its errors are prefixed with the probe name
+	factoryErr := errors.New("not today")
+	RegisterProbeType("fake-test", func(cfg ProbeConfig) (ProbeExecutor, error) {
+		return nil, factoryErr
+	})
+	_, err = NewProbeFromConfig(ProbeConfig{Name: "fake", Type: "fake-test"})
+	if !errors.Is(err, factoryErr) || !strings.HasPrefix(err.Error(), "probe fake: ") {
+		t.Fatalf("expected the replacement factory's error, got %v", err)
+	}
+}
+
+func TestNewProbeFromConfig_UnknownTypeAndMissingName(t *testing.T) {
+	_, err := NewProbeFromConfig(ProbeConfig{Name: "mystery", Type: "carrier-pigeon"})
+	if err == nil || !strings.Contains(err.Error(), `unknown type "carrier-pigeon"`) || !strings.Contains(err.Error(), "http, ") {
+		t.Fatalf("expected an unknown type error listing the known types, got %v", err)
+	}
+	if _, err := NewProbeFromConfig(ProbeConfig{Type: "http", URL: "https://example.com"}); err == nil {
+		t.Fatal("expected an error for a probe without a name")
+	}
+}
+
+func TestCreateConfiguredProbes(t *testing.T) {
+	registerTestProbeType(t, "fake-test", func(cfg ProbeConfig) (ProbeExecutor, error) {
+		return &fakeProbe{config: cfg}, nil
+	})
+	cfgs := []ProbeConfig{
+		{Name: "defaults", Type: "fake-test"},
+		{Name: "custom", Type: "fake-test", Timeout: "2s", Interval: "5m"},
+		{Name: "defaults", Type: "fake-test", Timeout: "1s"}, // Duplicate name
+		{Name: "bad-timeout", Type: "fake-test", Timeout: "soon"},
+		{Name: "bad-interval", Type: "fake-test", Interval: "-1m"},
+		{Name: "unknown", Type: "carrier-pigeon"},
+	}
+
+	probes, err := createConfiguredProbes(cfgs, 10*time.Second, time.Minute)
+
+	type schedule struct {
+		name              string
+		timeout, interval time.Duration
+	}
+	var got []schedule
+	for _, p := range probes {
+		got = append(got, schedule{p.config.Name, p.timeout, p.interval})
+	}
+	want := []schedule{
+		{"defaults", 10 * time.Second, time.Minute}, // Falls back to api_timeout and api_probe_interval
+		{"custom", 2 * time.Second, 5 * time.Minute},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("probes = %+v, want %+v", got, want)
+	}
+
+	if err == nil {
+		t.Fatal("expected the invalid definitions to be reported")
+	}
+	for _, problem := range []string{
+		`duplicate probe name "defaults"`,
+		"probe bad-timeout: invalid timeout",
+		"probe bad-interval: invalid interval",
+		`probe unknown: unknown type "carrier-pigeon"`,
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("error %q does not report %q", err, problem)
+		}
+	}
+}
@@ -14,6 +14,15 @@ type ProbeResult struct {
 	Latency    float64 // Latency in seconds
 	StatusCode int     // HTTP status code or connection state
 	Error      error   // Error information if the probe failed
+	Message    string  // Human-readable detail about the verdict, e.g. why a response was rejected
+	Assertions []AssertionResult // Outcome of each response body assertion, if any were configured
+	DNS        *DNSResult        // Set by dns probes
+	HTTP       *HTTPTimings      // Phase breakdown of http probes
+	Steps      []StepResult      // Outcome of each step of transaction probes
+	TCP        *TCPResult        // Set by tcp probes
+	GRPC       *GRPCResult       // Set by grpc probes, StatusCode then holds the health serving status
+	Exec       *ExecResult       // Set by exec probes, StatusCode then holds the Nagios state
+	LLM        *LLMResult        // Set by llm probes
 	Timestamp  time.Time
 }
 
@@ -0,0 +1,244 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// runningProbe tracks a probe loop started by the supervisor.
+type runningProbe struct {
+	probe  *scheduledProbe
+	env    string
+	cancel context.CancelFunc
+}
+
+// probeSupervisor owns every monitoring loop. When new settings are applied it
+// stops and starts only the loops whose effective configuration changed, so
+// unaffected probes keep their schedule and their current gauge values.
+type probeSupervisor struct {
+	mu         sync.Mutex
+	settings   *Settings
+	probes     map[string]*runningProbe
+	dxCancel   context.CancelFunc
+	dxDone     <-chan struct{} // Closed once the Direct Connect loop has exited
+	aiCancel   context.CancelFunc
+	aiDone     <-chan struct{} // Closed once the AI health check loop has exited
+	certCancel context.CancelFunc
+	certDone   <-chan struct{} // Closed once the certificate loop has exited
+}
+
+// newProbeSupervisor creates a supervisor with no running loops.
+func newProbeSupervisor() *probeSupervisor {
+	return &probeSupervisor{
+		probes: make(map[string]*runningProbe),
+	}
+}
+
+// apply brings the running loops in line with settings.
+// When strict is true an invalid probe definition rejects the whole settings and
+// leaves the current loops untouched; otherwise invalid probes are logged and skipped.
+func (s *probeSupervisor) apply(settings *Settings, strict bool) error {
+	newProbes, err := createConfiguredProbes(settings.Probes, settings.APITimeout, settings.APIProbeInterval)
+	if err != nil {
+		if strict {
+			return err
+		}
+		FmtLog(LogLevelError, "Some configured probes are invalid and will not run: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]*scheduledProbe, len(newProbes))
+	for _, p := range newProbes {
+		wanted[p.config.Name] = p
+	}
+
+	var started, stopped, unchanged int
+	for name, running := range s.probes {
+		next, ok := wanted[name]
+		if ok && running.env == settings.CurrentEnv && sameProbeSchedule(running.probe, next) {
+			delete(wanted, name)
+			unchanged++
+			continue
+		}
+		s.stopProbe(name, running)
+		stopped++
+	}
+	for _, p := range newProbes {
+		if _, ok := wanted[p.config.Name]; ok {
+			s.startProbe(p, settings.CurrentEnv)
+			started++
+		}
+	}
+	FmtLog(LogLevelInfo, "API probes applied: %d started, %d stopped, %d unchanged", started, stopped, unchanged)
+
+	prev := s.settings
+	if prev == nil || dxSettingsChanged(prev, settings) {
+		if s.dxCancel != nil {
+			FmtLog(LogLevelInfo, "Direct Connect settings changed, restarting Direct Connect monitoring")
+			s.dxCancel()
+			// The series of removed connections or of the previous env are not overwritten by the new loop
+			<-s.dxDone
+			resetDirectConnectMetrics()
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.dxCancel = cancel
+		s.dxDone = StartDirectConnectMonitoring(ctx, settings.AWS, settings.DXCollectTimeout, settings.DXCollectInterval, settings.CurrentEnv)
+	}
+	if prev == nil || aiSettingsChanged(prev, settings) {
+		if s.aiCancel != nil {
+			FmtLog(LogLevelInfo, "AI health check settings changed, restarting AI health check monitoring")
+			s.aiCancel()
+			<-s.aiDone
+			deleteAIHealthCheckMetrics(prev.CurrentEnv)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.aiCancel = cancel
+		s.aiDone = StartAIMonitoring(ctx, settings.AITimeout, settings.AIInterval, settings.AIProxy, settings.CurrentEnv)
+	}
+	if prev == nil || certSettingsChanged(prev, settings) {
+		if s.certCancel != nil {
+			FmtLog(LogLevelInfo, "Certificate targets changed, restarting certificate monitoring")
+			s.certCancel()
+			// Checks in flight may still export series of removed targets until the loop exits
+			<-s.certDone
+			CertificateTTLGauge.Reset()
+			CertificateCheckSuccessGauge.Reset()
+			CertificateChainTTLGauge.Reset()
+			CertificateInfoGauge.Reset()
+			CertificateRevocationStatusGauge.Reset()
+			CertificateOCSPAgeGauge.Reset()
+			CertificateOCSPNextUpdateGauge.Reset()
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.certCancel = cancel
+		s.certDone = StartCertificateMonitoring(ctx, settings.CertTargets, settings.CertRevocation, settings.CertTimeout, settings.CertInterval, settings.CurrentEnv)
+	}
+	if prev != nil && prev.MetricsPort != settings.MetricsPort {
+		FmtLog(LogLevelWarn, "metrics_port changed from %s to %s; a restart is required for it to take effect",
+			prev.MetricsPort, settings.MetricsPort)
+	}
+
+	s.settings = settings
+	return nil
+}
+
+// serveConfig exposes the active configuration with secrets redacted.
+func (s *probeSupervisor) serveConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	settings := s.settings
+	s.mu.Unlock()
+
+	if settings == nil || settings.Config == nil {
+		http.Error(w, "no configuration loaded", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+	w.Write([]byte(RedactedYAML(settings.Config)))
+}
+
+// startProbe launches the loop for probe. Callers must hold s.mu.
+func (s *probeSupervisor) startProbe(probe *scheduledProbe, currentEnv string) {
+	FmtLog(LogLevelInfo, "Starting probe %s (type=%s, url=%s, proxy=%s, interval=%v, timeout=%v)",
+		probe.config.Name, probe.config.Type, probe.config.URL, probe.config.Proxy, probe.interval, probe.timeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.probes[probe.config.Name] = &runningProbe{probe: probe, env: currentEnv, cancel: cancel}
+	exportProbeLabels(probe.config, currentEnv)
+	go runProbeLoop(ctx, probe, currentEnv)
+}
+
+// stopProbe cancels the loop for name and removes its series so stale values are not scraped.
+// Callers must hold s.mu.
+func (s *probeSupervisor) stopProbe(name string, running *runningProbe) {
+	FmtLog(LogLevelInfo, "Stopping probe %s", name)
+
+	running.cancel()
+	delete(s.probes, name)
+
+	labels := prometheus.Labels{"api_name": name, "env": running.env}
+	APIStatusGauge.Delete(labels)
+	APILatencyGauge.Delete(labels)
+	HTTPStatusCodeGauge.Delete(labels)
+	DNSAnswerCountGauge.Delete(labels)
+	TCPConnectLatencyGauge.Delete(labels)
+	GRPCHealthStatusGauge.Delete(labels)
+	TLSVerificationFailedGauge.Delete(labels)
+	ExecStateGauge.Delete(labels)
+	ExecPerfdataGauge.DeletePartialMatch(labels)
+	AIHealthStatusGauge.Delete(labels)
+	AIHealthLatencyGauge.Delete(labels)
+	AIHealthTTFTGauge.Delete(labels)
+	AIHealthTokensPerSecondGauge.Delete(labels)
+	AIHealthUsageTokensGauge.DeletePartialMatch(labels)
+	AIHealthRateLimitGauge.DeletePartialMatch(labels)
+	AIHealthRateLimitRemainingGauge.DeletePartialMatch(labels)
+	AssertionStatusGauge.DeletePartialMatch(labels)
+	ProbeLabelGauge.DeletePartialMatch(labels)
+	HTTPPhaseLatencyGauge.DeletePartialMatch(labels)
+	HTTPRemoteIPGauge.DeletePartialMatch(labels)
+	TransactionStepStatusGauge.DeletePartialMatch(labels)
+	TransactionStepLatencyGauge.DeletePartialMatch(labels)
+}
+
+// resetDirectConnectMetrics removes every Direct Connect series; they are only written by the
+// Direct Connect loop. Callers must wait for the loop to exit first.
+func resetDirectConnectMetrics() {
+	for _, gauge := range []*prometheus.GaugeVec{
+		DirectConnectBPSInGauge, DirectConnectBPSOutGauge,
+		DirectConnectPPSInGauge, DirectConnectPPSOutGauge,
+		DirectConnectPacketLossInGauge, DirectConnectPacketLossOutGauge,
+		DirectConnectErrorCountInGauge, DirectConnectErrorCountOutGauge,
+		DirectConnectCRCErrorCountGauge, DirectConnectConnectionStateGauge, DirectConnectCollectSuccessGauge,
+		DirectConnectAPIBPSInGauge, DirectConnectAPIBPSOutGauge,
+		DirectConnectAPIPPSInGauge, DirectConnectAPIPPSOutGauge,
+		DXAPIStatusGauge, DXAPILatencyGauge,
+	} {
+		gauge.Reset()
+	}
+}
+
+// deleteAIHealthCheckMetrics removes the series of the built-in AI health check for env.
+// The gauges are shared with llm probes, so only the health check's own series are deleted.
+// Callers must wait for the loop to exit first.
+func deleteAIHealthCheckMetrics(env string) {
+	labels := prometheus.Labels{"api_name": aiHealthCheckName, "env": env}
+	AIHealthStatusGauge.Delete(labels)
+	AIHealthLatencyGauge.Delete(labels)
+	HTTPStatusCodeGauge.Delete(labels)
+}
+
+// sameProbeSchedule reports whether two scheduled probes have identical definitions and timing.
+func sameProbeSchedule(a, b *scheduledProbe) bool {
+	return a.interval == b.interval && a.timeout == b.timeout && reflect.DeepEqual(a.config, b.config)
+}
+
+// dxSettingsChanged reports whether the Direct Connect loop must be restarted.
+func dxSettingsChanged(prev, next *Settings) bool {
+	return prev.CurrentEnv != next.CurrentEnv ||
+		prev.DXCollectTimeout != next.DXCollectTimeout ||
+		prev.DXCollectInterval != next.DXCollectInterval ||
+		!reflect.DeepEqual(prev.AWS, next.AWS)
+}
+
+// certSettingsChanged reports whether the certificate check loop must be restarted.
+func certSettingsChanged(prev, next *Settings) bool {
+	return prev.CurrentEnv != next.CurrentEnv ||
+		prev.CertTimeout != next.CertTimeout ||
+		prev.CertInterval != next.CertInterval ||
+		prev.CertRevocation != next.CertRevocation ||
+		!reflect.DeepEqual(prev.CertTargets, next.CertTargets)
+}
+
+// aiSettingsChanged reports whether the AI health check loop must be restarted.
+func aiSettingsChanged(prev, next *Settings) bool {
+	return prev.CurrentEnv != next.CurrentEnv ||
+		prev.AITimeout != next.AITimeout ||
+		prev.AIInterval != next.AIInterval ||
+		prev.AIProxy != next.AIProxy
+}
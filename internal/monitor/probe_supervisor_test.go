@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// testSupervisorSettings returns settings with a tcp probe against address for each name.
+func testSupervisorSettings(env, address string, names ...string) *Settings {
+	settings := &Settings{
+		APITimeout:        time.Second,
+		APIProbeInterval:  time.Hour,
+		DXCollectTimeout:  time.Second,
+		DXCollectInterval: time.Hour,
+		AITimeout:         time.Second,
+		AIInterval:        time.Hour,
+		CertTimeout:       time.Second,
+		CertInterval:      time.Hour,
+		CurrentEnv:        env,
+	}
+	for _, name := range names {
+		settings.Probes = append(settings.Probes, ProbeConfig{Name: name, Type: "tcp", TCP: TCPConfig{Address: address}})
+	}
+	return settings
+}
+
+// stopTestSupervisor stops every loop of s and waits for the background loops to exit.
+func stopTestSupervisor(s *probeSupervisor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, running := range s.probes {
+		s.stopProbe(name, running)
+	}
+	for _, stop := range []struct {
+		cancel func()
+		done   <-chan struct{}
+	}{{s.dxCancel, s.dxDone}, {s.aiCancel, s.aiDone}, {s.certCancel, s.certDone}} {
+		if stop.cancel != nil {
+			stop.cancel()
+			<-stop.done
+		}
+	}
+}
+
+func TestProbeSupervisor_ApplyRestartsOnlyChangedProbes(t *testing.T) {
+	address := startTestTCPServer(t)
+	s := newProbeSupervisor()
+	defer stopTestSupervisor(s)
+
+	if err := s.apply(testSupervisorSettings("test", address, "kept", "changed", "removed"), true); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	s.mu.Lock()
+	kept, changed := s.probes["kept"], s.probes["changed"]
+	s.mu.Unlock()
+	removedLabels := prometheus.Labels{"api_name": "removed", "env": "test"}
+	APIStatusGauge.With(removedLabels).Set(1)
+	TransactionStepStatusGauge.WithLabelValues("removed", "test", "login").Set(1)
+
+	next := testSupervisorSettings("test", address, "kept", "changed", "added")
+	next.Probes[1].Timeout = "2s"
+	if err := s.apply(next, true); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.probes["kept"] != kept {
+		t.Error("unchanged probe was restarted")
+	}
+	if s.probes["changed"] == changed || s.probes["changed"].probe.timeout != 2*time.Second {
+		t.Error("changed probe was not restarted with its new timeout")
+	}
+	if _, ok := s.probes["removed"]; ok {
+		t.Error("removed probe is still running")
+	}
+	if _, ok := s.probes["added"]; !ok {
+		t.Error("added probe was not started")
+	}
+	if APIStatusGauge.Delete(removedLabels) {
+		t.Error("series of the removed probe were not deleted")
+	}
+	if TransactionStepStatusGauge.DeletePartialMatch(removedLabels) != 0 {
+		t.Error("step series of the removed probe were not deleted")
+	}
+}
+
+func TestProbeSupervisor_EnvChangeRestartsEveryLoop(t *testing.T) {
+	address := startTestTCPServer(t)
+	s := newProbeSupervisor()
+	defer stopTestSupervisor(s)
+
+	if err := s.apply(testSupervisorSettings("old", address, "probe"), true); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	s.mu.Lock()
+	running := s.probes["probe"]
+	s.mu.Unlock()
+	APIStatusGauge.WithLabelValues("probe", "old").Set(1)
+	DirectConnectBPSInGauge.WithLabelValues("dxcon-test", "old").Set(1000)
+	DXAPIStatusGauge.WithLabelValues("dxcon-test", "old").Set(1)
+	AIHealthStatusGauge.WithLabelValues(aiHealthCheckName, "old").Set(1)
+	AIHealthStatusGauge.WithLabelValues("llm-probe", "old").Set(1) // Owned by an llm probe
+
+	if err := s.apply(testSupervisorSettings("new", address, "probe"), true); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	s.mu.Lock()
+	if s.probes["probe"] == running || s.probes["probe"].env != "new" {
+		t.Error("probe was not restarted for the new env")
+	}
+	s.mu.Unlock()
+	if APIStatusGauge.Delete(prometheus.Labels{"api_name": "probe", "env": "old"}) {
+		t.Error("probe series of the old env were not deleted")
+	}
+	if DirectConnectBPSInGauge.Delete(prometheus.Labels{"connection_id": "dxcon-test", "env": "old"}) ||
+		DXAPIStatusGauge.Delete(prometheus.Labels{"api_name": "dxcon-test", "env": "old"}) {
+		t.Error("Direct Connect series of the old env were not deleted")
+	}
+	if AIHealthStatusGauge.Delete(prometheus.Labels{"api_name": aiHealthCheckName, "env": "old"}) {
+		t.Error("AI health check series of the old env were not deleted")
+	}
+	if !AIHealthStatusGauge.Delete(prometheus.Labels{"api_name": "llm-probe", "env": "old"}) {
+		t.Error("series of an llm probe were deleted with the AI health check")
+	}
+}
+
+func TestProbeSupervisor_StrictApplyKeepsRunningProbes(t *testing.T) {
+	address := startTestTCPServer(t)
+	s := newProbeSupervisor()
+	defer stopTestSupervisor(s)
+
+	settings := testSupervisorSettings("test", address, "probe")
+	if err := s.apply(settings, true); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	s.mu.Lock()
+	running := s.probes["probe"]
+	s.mu.Unlock()
+
+	invalid := testSupervisorSettings("test", address, "probe", "broken")
+	invalid.Probes[0].Timeout = "2s"
+	invalid.Probes[1].Type = "no-such-type"
+	if err := s.apply(invalid, true); err == nil {
+		t.Fatal("expected an error for an unknown probe type")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.probes["probe"] != running || len(s.probes) != 1 || s.settings != settings {
+		t.Error("rejected settings changed the running probes")
+	}
+}
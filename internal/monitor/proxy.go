@@ -0,0 +1,209 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+// Special values for ProxyConfig.URL.
+const (
+	proxyModeNone = "none" // Connect directly (default)
+	proxyModeEnv  = "env"  // Honor HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+)
+
+// ProxyConfig selects how a probe reaches its target.
+//
+//	url: none | env | http://host:port | https://host:port | socks5://host:port | socks5h://host:port
+//
+// Username and password are sent as proxy credentials (Proxy-Authorization for HTTP CONNECT,
+// username/password authentication for SOCKS5).
+type ProxyConfig struct {
+	URL      string `yaml:"url"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password" secret:"true"`
+}
+
+// String describes the proxy without credentials, for logging.
+func (c ProxyConfig) String() string {
+	if c.URL == "" {
+		return proxyModeNone
+	}
+	if u, err := url.Parse(c.URL); err == nil && u.User != nil {
+		u.User = nil
+		return u.String()
+	}
+	return c.URL
+}
+
+// newProxyFunc builds the http.Transport.Proxy function for cfg. A nil function means a direct connection.
+func newProxyFunc(cfg ProxyConfig) (func(*http.Request) (*url.URL, error), error) {
+	switch strings.ToLower(cfg.URL) {
+	case "", proxyModeNone:
+		if cfg.Username != "" || cfg.Password != "" {
+			return nil, fmt.Errorf("proxy credentials set without a proxy url")
+		}
+		return nil, nil
+	case proxyModeEnv:
+		if cfg.Username != "" || cfg.Password != "" {
+			return nil, fmt.Errorf("proxy credentials cannot be combined with proxy url %q", proxyModeEnv)
+		}
+		// Read each time a probe is built; http.ProxyFromEnvironment caches it for the whole process
+		proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
+		return func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}, nil
+	}
+
+	proxyURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy url %q: %w", cfg.String(), err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (expected http, https, socks5 or socks5h)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy url %q: missing host", cfg.String())
+	}
+	if cfg.Username != "" {
+		proxyURL.User = url.UserPassword(cfg.Username, cfg.Password)
+	} else if cfg.Password != "" {
+		return nil, fmt.Errorf("proxy password set without a username")
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// proxyDialFunc opens a TCP connection to addr, possibly through a proxy.
+type proxyDialFunc func(ctx context.Context, addr string) (net.Conn, error)
+
+// newProxyDialer builds a dial function for probes that do not go through http.Transport.
+// HTTP and HTTPS proxies are used with CONNECT, SOCKS5 proxies directly. In env mode secure
+// selects HTTPS_PROXY rather than HTTP_PROXY, as for https:// and wss:// targets.
+func newProxyDialer(cfg ProxyConfig, secure bool) (proxyDialFunc, error) {
+	proxyFunc, err := newProxyFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+	direct := &net.Dialer{}
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		if proxyFunc == nil {
+			return direct.DialContext(ctx, "tcp", addr)
+		}
+		scheme := "http"
+		if secure {
+			scheme = "https"
+		}
+		proxyURL, err := proxyFunc(&http.Request{URL: &url.URL{Scheme: scheme, Host: addr}})
+		if err != nil {
+			return nil, err
+		}
+		if proxyURL == nil { // Excluded by NO_PROXY
+			return direct.DialContext(ctx, "tcp", addr)
+		}
+
+		switch proxyURL.Scheme {
+		case "socks5", "socks5h":
+			var auth *proxy.Auth
+			if proxyURL.User != nil {
+				password, _ := proxyURL.User.Password()
+				auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+			}
+			dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, direct)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+		case "http", "https":
+			return dialHTTPConnect(ctx, direct, proxyURL, addr)
+		}
+		return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+	}, nil
+}
+
+// dialHTTPConnect opens a tunnel to addr with an HTTP CONNECT request to proxyURL.
+func dialHTTPConnect(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), map[string]string{"http": "80", "https": "443"}[proxyURL.Scheme])
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy %s: %w", proxyURL.Redacted(), err)
+		}
+		conn = tlsConn
+	}
+	// Abort the exchange with the proxy when ctx is done
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused CONNECT to %s: %s", proxyURL.Redacted(), addr, resp.Status)
+	}
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	if br.Buffered() > 0 {
+		// Servers that speak first, e.g. SMTP, may already have sent their greeting
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn whose first reads are served from data already buffered in r.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
+
+// rejectProxy reports an error when cfg is set on a probe type that cannot use a proxy,
+// instead of silently connecting directly.
+func rejectProxy(cfg ProxyConfig, probeType string) error {
+	if cfg != (ProxyConfig{}) {
+		return fmt.Errorf("proxy is not supported by %s probes", probeType)
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startTestConnectProxy starts an HTTP proxy that only supports CONNECT and returns its url
+// together with the number of tunnels it opened. A non-empty auth must be sent as the
+// Proxy-Authorization header.
+func startTestConnectProxy(t *testing.T, auth string) (string, *atomic.Int32) {
+	t.Helper()
+	var tunnels atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		if auth != "" && r.Header.Get("Proxy-Authorization") != auth {
+			http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+			return
+		}
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+		client, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer client.Close()
+		tunnels.Add(1)
+		io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(upstream, client)
+		io.Copy(client, upstream)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy.URL, &tunnels
+}
+
+// startTestSOCKS5Proxy starts a SOCKS5 proxy that requires username/password authentication
+// and returns its host:port together with the number of tunnels it opened.
+func startTestSOCKS5Proxy(t *testing.T, username, password string) (string, *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	var tunnels atomic.Int32
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				upstream, err := acceptTestSOCKS5(conn, username, password)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				tunnels.Add(1)
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return ln.Addr().String(), &tunnels
+}
+
+// acceptTestSOCKS5 runs the server side of a SOCKS5 handshake (RFC 1928 and RFC 1929) on conn
+// and returns the connection to the requested address.
+func acceptTestSOCKS5(conn net.Conn, username, password string) (net.Conn, error) {
+	r := bufio.NewReader(conn)
+	readN := func(n int) ([]byte, error) {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(r, buf)
+		return buf, err
+	}
+
+	// Greeting: version, methods; only username/password (0x02) is accepted
+	header, err := readN(2)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := readN(int(header[1]))
+	if err != nil || !strings.Contains(string(methods), "\x02") {
+		conn.Write([]byte{5, 0xff})
+		return nil, io.ErrUnexpectedEOF
+	}
+	conn.Write([]byte{5, 2})
+
+	// Username/password sub-negotiation
+	ulen, err := readN(2)
+	if err != nil {
+		return nil, err
+	}
+	user, err := readN(int(ulen[1]))
+	if err != nil {
+		return nil, err
+	}
+	plen, err := readN(1)
+	if err != nil {
+		return nil, err
+	}
+	pass, err := readN(int(plen[0]))
+	if err != nil {
+		return nil, err
+	}
+	if string(user) != username || string(pass) != password {
+		conn.Write([]byte{1, 1})
+		return nil, io.ErrUnexpectedEOF
+	}
+	conn.Write([]byte{1, 0})
+
+	// CONNECT request
+	req, err := readN(4)
+	if err != nil {
+		return nil, err
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip, err := readN(4)
+		if err != nil {
+			return nil, err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		n, err := readN(1)
+		if err != nil {
+			return nil, err
+		}
+		name, err := readN(int(n[0]))
+		if err != nil {
+			return nil, err
+		}
+		host = string(name)
+	default:
+		return nil, io.ErrUnexpectedEOF
+	}
+	port, err := readN(2)
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return nil, err
+	}
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	return upstream, nil
+}
+
+// dialTestBanner dials address with cfg and returns the banner of startTestTCPServer.
+func dialTestBanner(t *testing.T, cfg ProxyConfig, address string) (string, error) {
+	t.Helper()
+	dial, err := newProxyDialer(cfg, false)
+	if err != nil {
+		t.Fatalf("newProxyDialer failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := dial(ctx, address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	return bufio.NewReader(conn).ReadString('\n')
+}
+
+func TestProxyDialer_HTTPConnectWithCredentials(t *testing.T) {
+	address := startTestTCPServer(t)
+	// Basic base64("monitor:s3cret")
+	proxyURL, tunnels := startTestConnectProxy(t, "Basic bW9uaXRvcjpzM2NyZXQ=")
+
+	banner, err := dialTestBanner(t, ProxyConfig{URL: proxyURL, Username: "monitor", Password: "s3cret"}, address)
+	if err != nil || banner != "220 test ready\r\n" || tunnels.Load() != 1 {
+		t.Fatalf("got banner %q, err %v after %d tunnels", banner, err, tunnels.Load())
+	}
+
+	_, err = dialTestBanner(t, ProxyConfig{URL: proxyURL, Username: "monitor", Password: "wrong"}, address)
+	if err == nil || !strings.Contains(err.Error(), "407") {
+		t.Fatalf("expected the proxy to refuse wrong credentials, got %v", err)
+	}
+}
+
+func TestProxyDialer_SOCKS5WithCredentials(t *testing.T) {
+	address := startTestTCPServer(t)
+	proxyAddr, tunnels := startTestSOCKS5Proxy(t, "monitor", "s3cret")
+
+	for _, scheme := range []string{"socks5", "socks5h"} {
+		cfg := ProxyConfig{URL: scheme + "://" + proxyAddr, Username: "monitor", Password: "s3cret"}
+		banner, err := dialTestBanner(t, cfg, address)
+		if err != nil || banner != "220 test ready\r\n" {
+			t.Fatalf("%s: got banner %q, err %v", scheme, banner, err)
+		}
+	}
+	if tunnels.Load() != 2 {
+		t.Fatalf("expected 2 tunnels, got %d", tunnels.Load())
+	}
+
+	if _, err := dialTestBanner(t, ProxyConfig{URL: "socks5://" + proxyAddr, Username: "monitor", Password: "wrong"}, address); err == nil {
+		t.Fatal("expected the proxy to refuse wrong credentials")
+	}
+}
+
+func TestNewProxyFunc_EnvHonorsNoProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://proxy.example.com:3128")
+	t.Setenv("HTTPS_PROXY", "http://secure-proxy.example.com:3128")
+	t.Setenv("NO_PROXY", "internal.example.com")
+
+	proxyFunc, err := newProxyFunc(ProxyConfig{URL: "env"})
+	if err != nil {
+		t.Fatalf("newProxyFunc failed: %v", err)
+	}
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"http://api.example.com/status", "http://proxy.example.com:3128"},
+		{"https://api.example.com/status", "http://secure-proxy.example.com:3128"},
+		{"https://svc.internal.example.com/status", ""},
+		{"http://127.0.0.1:8080/status", ""}, // Loopback is never proxied
+	}
+	for _, tt := range tests {
+		target, _ := url.Parse(tt.target)
+		got, err := proxyFunc(&http.Request{URL: target})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.target, err)
+		}
+		if (got == nil && tt.want != "") || (got != nil && got.String() != tt.want) {
+			t.Errorf("%s: proxy %v, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestNewProxyFunc_None(t *testing.T) {
+	for _, value := range []string{"", "none", "NONE"} {
+		proxyFunc, err := newProxyFunc(ProxyConfig{URL: value})
+		if err != nil || proxyFunc != nil {
+			t.Errorf("url %q: expected a direct connection, got err %v", value, err)
+		}
+	}
+
+	address := startTestTCPServer(t)
+	if banner, err := dialTestBanner(t, ProxyConfig{URL: "none"}, address); err != nil || banner != "220 test ready\r\n" {
+		t.Fatalf("expected a direct connection, got banner %q, err %v", banner, err)
+	}
+}
+
+func TestNewProxyFunc_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ProxyConfig
+	}{
+		{"ftp scheme", ProxyConfig{URL: "ftp://proxy.example.com:21"}},
+		{"socks4 scheme", ProxyConfig{URL: "socks4://proxy.example.com:1080"}},
+		{"missing scheme", ProxyConfig{URL: "proxy.example.com:3128"}},
+		{"missing host", ProxyConfig{URL: "http://"}},
+		{"password without username", ProxyConfig{URL: "http://proxy.example.com:3128", Password: "s3cret"}},
+		{"credentials without url", ProxyConfig{Username: "monitor", Password: "s3cret"}},
+		{"credentials with env", ProxyConfig{URL: "env", Username: "monitor", Password: "s3cret"}},
+	}
+	for _, tt := range tests {
+		if _, err := newProxyFunc(tt.cfg); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+		if _, err := newProxyDialer(tt.cfg, false); err == nil {
+			t.Errorf("%s: expected newProxyDialer to fail too", tt.name)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// maxRevocationResponseBytes caps the size of downloaded OCSP responses and CRLs; CRLs of
+// large public CAs run to a few megabytes.
+const maxRevocationResponseBytes = 32 << 20
+
+// CertificateRevocationConfig enables revocation checks of the leaf certificate of every
+// certificate target. Both are off by default since they contact the issuing CA.
+type CertificateRevocationConfig struct {
+	OCSP bool `yaml:"ocsp"` // Stapled response, then the responders listed in the certificate
+	CRL  bool `yaml:"crl"`  // Distribution points listed in the certificate, used when OCSP gives no answer
+}
+
+// Enabled reports whether any revocation method is enabled.
+func (c CertificateRevocationConfig) Enabled() bool {
+	return c.OCSP || c.CRL
+}
+
+// RevocationStatus is the revocation state of a certificate, exported as the value of
+// api_certificate_revocation_status.
+type RevocationStatus int
+
+const (
+	RevocationGood    RevocationStatus = 0
+	RevocationRevoked RevocationStatus = 1
+	RevocationUnknown RevocationStatus = 2 // No method gave an answer, or the responder does not know the certificate
+)
+
+func (s RevocationStatus) String() string {
+	switch s {
+	case RevocationGood:
+		return "good"
+	case RevocationRevoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+// Revocation methods reported in RevocationResult.Method.
+const (
+	RevocationMethodOCSPStapled = "ocsp_stapled"
+	RevocationMethodOCSP        = "ocsp"
+	RevocationMethodCRL         = "crl"
+	RevocationMethodNone        = "none"
+)
+
+// RevocationResult is the outcome of a revocation check of a leaf certificate.
+type RevocationResult struct {
+	Status     RevocationStatus
+	Method     string    // Method that produced Status, RevocationMethodNone if none did
+	RevokedAt  time.Time // Set for revoked certificates
+	ThisUpdate time.Time // Issue time of the OCSP response or CRL
+	NextUpdate time.Time // Expiry of the OCSP response or CRL, zero if not given
+	Err        error     // Why a method failed; set whenever Status is unknown
+}
+
+// checkRevocation checks whether the leaf of certs has been revoked using the methods
+// enabled in cfg. stapled is the OCSP response stapled to the handshake, if any. OCSP is
+// tried first as it is cheaper; CRLs are consulted when OCSP gave no definite answer.
+// Requests are made with transport, http.DefaultTransport when nil.
+func checkRevocation(ctx context.Context, certs []*x509.Certificate, stapled []byte, cfg CertificateRevocationConfig, timeout time.Duration, transport http.RoundTripper) *RevocationResult {
+	leaf := certs[0]
+	issuer := findIssuer(leaf, certs[1:])
+	if issuer == nil {
+		return &RevocationResult{Status: RevocationUnknown, Method: RevocationMethodNone,
+			Err: errors.New("issuer certificate of the leaf is not part of the served chain")}
+	}
+
+	client := &http.Client{Timeout: timeout, Transport: transport}
+	var errs []error
+	var unknown *RevocationResult // Definite "unknown" from a responder, kept if the CRL gives no answer
+	if cfg.OCSP {
+		result, err := checkOCSP(ctx, client, leaf, issuer, stapled)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case result.Status != RevocationUnknown:
+			return result
+		default:
+			unknown = result
+		}
+	}
+	if cfg.CRL {
+		result, err := checkCRL(ctx, client, leaf, issuer)
+		if err == nil {
+			return result
+		}
+		errs = append(errs, err)
+	}
+
+	if unknown == nil {
+		unknown = &RevocationResult{Status: RevocationUnknown, Method: RevocationMethodNone}
+	}
+	unknown.Err = errors.Join(append(errs, unknown.Err)...)
+	return unknown
+}
+
+// findIssuer returns the certificate of chain that signed cert, or nil.
+func findIssuer(cert *x509.Certificate, chain []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range chain {
+		if cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// checkOCSP evaluates the stapled response, falling back to the responders of leaf when
+// nothing was stapled or the stapled response is invalid.
+func checkOCSP(ctx context.Context, client *http.Client, leaf, issuer *x509.Certificate, stapled []byte) (*RevocationResult, error) {
+	var errs []error
+	if len(stapled) > 0 {
+		resp, err := ocsp.ParseResponseForCert(stapled, leaf, issuer)
+		if err == nil {
+			return ocspResult(resp, RevocationMethodOCSPStapled), nil
+		}
+		errs = append(errs, fmt.Errorf("invalid stapled OCSP response: %w", err))
+	}
+	if len(leaf.OCSPServer) == 0 {
+		return nil, errors.Join(append(errs, errors.New("certificate lists no OCSP responder"))...)
+	}
+
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCSP request: %w", err)
+	}
+	for _, server := range leaf.OCSPServer {
+		body, err := fetchRevocationData(ctx, client, http.MethodPost, server, request)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("OCSP responder %s: %w", server, err))
+			continue
+		}
+		resp, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("OCSP responder %s: invalid response: %w", server, err))
+			continue
+		}
+		return ocspResult(resp, RevocationMethodOCSP), nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// ocspResult converts a verified OCSP response.
+func ocspResult(resp *ocsp.Response, method string) *RevocationResult {
+	result := &RevocationResult{
+		Method:     method,
+		ThisUpdate: resp.ThisUpdate,
+		NextUpdate: resp.NextUpdate,
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		result.Status = RevocationGood
+	case ocsp.Revoked:
+		result.Status, result.RevokedAt = RevocationRevoked, resp.RevokedAt
+	default:
+		result.Status = RevocationUnknown
+		result.Err = errors.New("OCSP responder does not know the certificate")
+	}
+	return result
+}
+
+// checkCRL downloads the first CRL of leaf's distribution points that can be verified
+// against issuer and looks up the leaf's serial number in it.
+func checkCRL(ctx context.Context, client *http.Client, leaf, issuer *x509.Certificate) (*RevocationResult, error) {
+	var errs []error
+	for _, point := range leaf.CRLDistributionPoints {
+		if !strings.HasPrefix(point, "http://") && !strings.HasPrefix(point, "https://") {
+			continue
+		}
+		body, err := fetchRevocationData(ctx, client, http.MethodGet, point, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("CRL %s: %w", point, err))
+			continue
+		}
+		crl, err := x509.ParseRevocationList(body)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("CRL %s: %w", point, err))
+			continue
+		}
+		if err := crl.CheckSignatureFrom(issuer); err != nil {
+			errs = append(errs, fmt.Errorf("CRL %s: not signed by the issuer: %w", point, err))
+			continue
+		}
+		if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+			errs = append(errs, fmt.Errorf("CRL %s: expired at %s", point, crl.NextUpdate.Format(time.RFC3339)))
+			continue
+		}
+
+		result := &RevocationResult{
+			Status:     RevocationGood,
+			Method:     RevocationMethodCRL,
+			ThisUpdate: crl.ThisUpdate,
+			NextUpdate: crl.NextUpdate,
+		}
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+				result.Status, result.RevokedAt = RevocationRevoked, entry.RevocationTime
+				break
+			}
+		}
+		return result, nil
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("certificate lists no HTTP CRL distribution point")
+	}
+	return nil, errors.Join(errs...)
+}
+
+// fetchRevocationData sends an OCSP request (POST) or downloads a CRL (GET) and returns
+// the response body.
+func fetchRevocationData(ctx context.Context, client *http.Client, method, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/ocsp-request")
+		req.Header.Set("Accept", "application/ocsp-response")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRevocationResponseBytes {
+		return nil, fmt.Errorf("response larger than %d bytes", maxRevocationResponseBytes)
+	}
+	return data, nil
+}
@@ -0,0 +1,326 @@
+package monitor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/ocsp"
+)
+
+// revocationTestPKI is a CA, a leaf it issued and a local stand-in for the CA's OCSP
+// responder and CRL distribution point.
+type revocationTestPKI struct {
+	ca       *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+	leaf     *x509.Certificate
+	leafKey  *ecdsa.PrivateKey
+	ocspCode int         // OCSP response status returned by the responder, -1 for HTTP 500
+	crl      []byte      // DER CRL served at the distribution point
+	requests chan string // Paths requested from the responder
+}
+
+func newRevocationTestPKI(t *testing.T) *revocationTestPKI {
+	t.Helper()
+	pki := &revocationTestPKI{ocspCode: ocsp.Good, requests: make(chan string, 10)}
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pki.requests <- r.Method + " " + r.URL.Path
+		switch r.URL.Path {
+		case "/ocsp":
+			body, _ := io.ReadAll(r.Body)
+			req, err := ocsp.ParseRequest(body)
+			if err != nil || pki.ocspCode < 0 || req.SerialNumber.Cmp(pki.leaf.SerialNumber) != 0 {
+				http.Error(w, "responder failure", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/ocsp-response")
+			w.Write(pki.ocspResponse(t, pki.ocspCode))
+		case "/ca.crl":
+			w.Write(pki.crl)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(responder.Close)
+
+	now := time.Now()
+	pki.caKey = newTestKey(t)
+	pki.ca = issueTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Revocation Test CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, pki.caKey, nil)
+	pki.leafKey = newTestKey(t)
+	pki.leaf = issueTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(4242),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(12 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:              []string{"localhost"},
+		OCSPServer:            []string{responder.URL + "/ocsp"},
+		CRLDistributionPoints: []string{responder.URL + "/ca.crl"},
+	}, pki.ca, pki.leafKey, pki.caKey)
+	pki.crl = pki.revocationList(t, pki.caKey)
+	return pki
+}
+
+// ocspResponse signs a response with the given status for the leaf.
+func (pki *revocationTestPKI) ocspResponse(t *testing.T, status int) []byte {
+	now := time.Now()
+	template := ocsp.Response{
+		Status:       status,
+		SerialNumber: pki.leaf.SerialNumber,
+		ThisUpdate:   now.Add(-10 * time.Minute),
+		NextUpdate:   now.Add(time.Hour),
+	}
+	if status == ocsp.Revoked {
+		template.RevokedAt = now.Add(-time.Hour)
+		template.RevocationReason = ocsp.KeyCompromise
+	}
+	resp, err := ocsp.CreateResponse(pki.ca, pki.ca, template, pki.caKey)
+	if err != nil {
+		t.Errorf("create OCSP response: %v", err) // Also called from the responder goroutine
+	}
+	return resp
+}
+
+// revocationList signs a CRL with key listing the given serial numbers.
+func (pki *revocationTestPKI) revocationList(t *testing.T, key *ecdsa.PrivateKey, revoked ...*big.Int) []byte {
+	t.Helper()
+	now := time.Now()
+	template := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: now.Add(-time.Hour),
+		NextUpdate: now.Add(time.Hour),
+	}
+	for _, serial := range revoked {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries,
+			x509.RevocationListEntry{SerialNumber: serial, RevocationTime: now.Add(-30 * time.Minute)})
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, template, pki.ca, key)
+	if err != nil {
+		t.Fatalf("create CRL: %v", err)
+	}
+	return crl
+}
+
+// serve starts a TLS server presenting the leaf and the CA, stapling staple if set.
+func (pki *revocationTestPKI) serve(t *testing.T, staple []byte) string {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{
+		Certificate: [][]byte{pki.leaf.Raw, pki.ca.Raw},
+		PrivateKey:  pki.leafKey,
+		OCSPStaple:  staple,
+	}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestInspectCertificate_Revocation(t *testing.T) {
+	otherKey := newTestKey(t)
+
+	tests := []struct {
+		name       string
+		revocation CertificateRevocationConfig
+		setup      func(t *testing.T, pki *revocationTestPKI) (staple []byte)
+		wantStatus RevocationStatus
+		wantMethod string
+		wantErr    string
+	}{
+		{name: "ocsp good", revocation: CertificateRevocationConfig{OCSP: true},
+			wantStatus: RevocationGood, wantMethod: RevocationMethodOCSP},
+		{name: "ocsp revoked", revocation: CertificateRevocationConfig{OCSP: true, CRL: true},
+			setup: func(t *testing.T, pki *revocationTestPKI) []byte {
+				pki.ocspCode = ocsp.Revoked
+				return nil
+			},
+			wantStatus: RevocationRevoked, wantMethod: RevocationMethodOCSP},
+		{name: "stapled response preferred over responder", revocation: CertificateRevocationConfig{OCSP: true},
+			setup: func(t *testing.T, pki *revocationTestPKI) []byte {
+				return pki.ocspResponse(t, ocsp.Revoked)
+			},
+			wantStatus: RevocationRevoked, wantMethod: RevocationMethodOCSPStapled},
+		{name: "ocsp unknown", revocation: CertificateRevocationConfig{OCSP: true},
+			setup: func(t *testing.T, pki *revocationTestPKI) []byte {
+				pki.ocspCode = ocsp.Unknown
+				return nil
+			},
+			wantStatus: RevocationUnknown, wantMethod: RevocationMethodOCSP, wantErr: "does not know the certificate"},
+		{name: "responder failure", revocation: CertificateRevocationConfig{OCSP: true},
+			setup: func(t *testing.T, pki *revocationTestPKI) []byte {
+				pki.ocspCode = -1
+				return nil
+			},
+			wantStatus: RevocationUnknown, wantMethod: RevocationMethodNone, wantErr: "500 Internal Server Error"},
+		{name: "crl fallback after responder failure", revocation: CertificateRevocationConfig{OCSP: true, CRL: true},
+			setup: func(t *testing.T, pki *revocationTestPKI) []byte {
+				pki.ocspCode = -1
+				pki.crl = pki.revocationList(t, pki.caKey, big.NewInt(1), pki.leaf.SerialNumber)
+				return nil
+			},
+			wantStatus: RevocationRevoked, wantMethod: RevocationMethodCRL},
+		{name: "crl good", revocation: CertificateRevocationConfig{CRL: true},
+			setup: func(t *testing.T, pki *revocationTestPKI) []byte {
+				pki.crl = pki.revocationList(t, pki.caKey, big.NewInt(1))
+				return nil
+			},
+			wantStatus: RevocationGood, wantMethod: RevocationMethodCRL},
+		{name: "crl not signed by issuer", revocation: CertificateRevocationConfig{CRL: true},
+			setup: func(t *testing.T, pki *revocationTestPKI) []byte {
+				pki.crl = pki.revocationList(t, otherKey)
+				return nil
+			},
+			wantStatus: RevocationUnknown, wantMethod: RevocationMethodNone, wantErr: "not signed by the issuer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pki := newRevocationTestPKI(t)
+			var staple []byte
+			if tt.setup != nil {
+				staple = tt.setup(t, pki)
+			}
+			chain, result, err := InspectCertificate(context.Background(), pki.serve(t, staple), 2*time.Second, tt.revocation)
+			if err != nil {
+				t.Fatalf("InspectCertificate failed: %v", err)
+			}
+			if len(chain) != 2 {
+				t.Fatalf("expected leaf and CA, got %d certificates", len(chain))
+			}
+			if result.Status != tt.wantStatus || result.Method != tt.wantMethod {
+				t.Fatalf("got %s via %s (%v), want %s via %s", result.Status, result.Method, result.Err, tt.wantStatus, tt.wantMethod)
+			}
+			if tt.wantErr != "" && (result.Err == nil || !strings.Contains(result.Err.Error(), tt.wantErr)) {
+				t.Errorf("got error %v, want %q", result.Err, tt.wantErr)
+			}
+			if result.Status == RevocationRevoked && result.RevokedAt.IsZero() {
+				t.Error("expected the revocation time")
+			}
+			if tt.wantMethod == RevocationMethodOCSPStapled && len(pki.requests) != 0 {
+				t.Errorf("responder contacted although a response was stapled: %s", <-pki.requests)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		pki := newRevocationTestPKI(t)
+		_, result, err := InspectCertificate(context.Background(), pki.serve(t, nil), 2*time.Second, CertificateRevocationConfig{})
+		if err != nil || result != nil {
+			t.Fatalf("got %+v, %v; want no revocation check", result, err)
+		}
+		if len(pki.requests) != 0 {
+			t.Errorf("responder contacted with revocation checks disabled: %s", <-pki.requests)
+		}
+	})
+}
+
+// startTestForwardProxy starts an HTTP proxy that tunnels CONNECT requests and forwards plain
+// http requests, and returns its url together with the forwarded requests.
+func startTestForwardProxy(t *testing.T) (string, chan string) {
+	t.Helper()
+	forwarded := make(chan string, 10)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			upstream, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			defer upstream.Close()
+			client, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				return
+			}
+			defer client.Close()
+			io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+			go io.Copy(upstream, client)
+			io.Copy(client, upstream)
+			return
+		}
+		forwarded <- r.Method + " " + r.URL.String()
+		r.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy.URL, forwarded
+}
+
+func TestInspectCertificateTarget_RevocationThroughProxy(t *testing.T) {
+	pki := newRevocationTestPKI(t)
+	proxyURL, forwarded := startTestForwardProxy(t)
+	target := CertificateTargetConfig{Name: "proxied", Target: pki.serve(t, nil), Proxy: ProxyConfig{URL: proxyURL}}
+
+	pki.ocspCode = -1 // Fall through to the CRL so both requests are made
+	_, result, err := inspectCertificateTarget(context.Background(), target, 2*time.Second, CertificateRevocationConfig{OCSP: true, CRL: true})
+	if err != nil {
+		t.Fatalf("inspectCertificateTarget failed: %v", err)
+	}
+	if result.Status != RevocationGood || result.Method != RevocationMethodCRL {
+		t.Fatalf("got %s via %s (%v), want good via crl", result.Status, result.Method, result.Err)
+	}
+	close(forwarded)
+	var requests []string
+	for r := range forwarded {
+		requests = append(requests, r)
+	}
+	want := []string{"POST " + pki.leaf.OCSPServer[0], "GET " + pki.leaf.CRLDistributionPoints[0]}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("proxy forwarded %q, want %q", requests, want)
+	}
+}
+
+func TestExportRevocation(t *testing.T) {
+	labels := prometheus.Labels{"api_name": "revocation-test", "env": "test"}
+	defer func() {
+		CertificateRevocationStatusGauge.DeletePartialMatch(labels)
+		CertificateOCSPAgeGauge.Delete(labels)
+		CertificateOCSPNextUpdateGauge.Delete(labels)
+	}()
+
+	now := time.Now()
+	exportRevocation("revocation-test", "test", &RevocationResult{
+		Status:     RevocationRevoked,
+		Method:     RevocationMethodOCSPStapled,
+		RevokedAt:  now.Add(-time.Hour),
+		ThisUpdate: now.Add(-2 * time.Hour),
+		NextUpdate: now.Add(-time.Hour),
+	})
+	status := testutil.ToFloat64(CertificateRevocationStatusGauge.WithLabelValues("revocation-test", "test", RevocationMethodOCSPStapled))
+	if status != float64(RevocationRevoked) {
+		t.Errorf("revocation status = %v, want %d", status, RevocationRevoked)
+	}
+	if age := testutil.ToFloat64(CertificateOCSPAgeGauge.With(labels)); age < 2*3600 || age > 2*3600+60 {
+		t.Errorf("OCSP age = %.0fs, want about 2h", age)
+	}
+	if next := testutil.ToFloat64(CertificateOCSPNextUpdateGauge.With(labels)); next > -3500 {
+		t.Errorf("OCSP next update = %.0fs, want a stale response of about -1h", next)
+	}
+}
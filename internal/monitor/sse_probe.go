@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxSSELineBytes caps the length of a single line of an event stream.
+const maxSSELineBytes = 1 << 20
+
+// SSEConfig defines what an "sse" probe waits for on its event stream.
+type SSEConfig struct {
+	Event  string `yaml:"event"`  // Optional event type to wait for, any event if empty
+	Expect string `yaml:"expect"` // Optional regular expression the event data must match
+}
+
+// SSEProbe is an implementation of ProbeExecutor that opens a Server-Sent Events stream and
+// waits for the first event. Latency is the time to that first event.
+type SSEProbe struct {
+	Client         *http.Client
+	URL            string
+	Name           string
+	ExpectedStatus *StatusMatcher // Accepted status codes, 200 if nil
+	Event          string
+	Expect         *regexp.Regexp
+}
+
+// sseEvent is a dispatched Server-Sent Event.
+type sseEvent struct {
+	event string
+	data  string
+}
+
+// newSSEProbeFromConfig builds an SSEProbe for the "sse" probe type.
+func newSSEProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if err := validateHTTPURL(cfg.URL); err != nil {
+		return nil, err
+	}
+	proxy, err := newProxyFunc(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newTLSClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	probe := &SSEProbe{
+		Client: &http.Client{
+			Transport: &http.Transport{Proxy: proxy, TLSClientConfig: tlsConfig},
+		},
+		URL:            cfg.URL,
+		Name:           cfg.Name,
+		ExpectedStatus: mustParseStatusMatcher([]string{"200"}),
+		Event:          cfg.SSE.Event,
+	}
+	if len(cfg.ExpectedStatus) > 0 {
+		if probe.ExpectedStatus, err = ParseStatusMatcher(cfg.ExpectedStatus); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.SSE.Expect != "" {
+		if probe.Expect, err = regexp.Compile(cfg.SSE.Expect); err != nil {
+			return nil, fmt.Errorf("invalid sse.expect %q: %w", cfg.SSE.Expect, err)
+		}
+	}
+	return probe, nil
+}
+
+// Execute implements the ProbeExecutor interface.
+func (p *SSEProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return NewProbeResult(p.Name, 0, 0, 0, err), err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		err = classifyTLSError(err)
+		return NewProbeResult(p.Name, 0, time.Since(start).Seconds(), 0, err), err
+	}
+	defer resp.Body.Close()
+
+	if !p.ExpectedStatus.Match(resp.StatusCode) {
+		return httpStatusResult(p.Name, p.ExpectedStatus, time.Since(start).Seconds(), resp.StatusCode), nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		result := NewProbeResult(p.Name, 0, time.Since(start).Seconds(), resp.StatusCode, nil)
+		result.Message = fmt.Sprintf("content type %q is not text/event-stream", resp.Header.Get("Content-Type"))
+		return result, nil
+	}
+
+	event, err := p.firstEvent(resp.Body)
+	result := NewProbeResult(p.Name, 1, time.Since(start).Seconds(), resp.StatusCode, nil)
+	switch {
+	case err != nil:
+		result.Status = 0
+		result.Message = fmt.Sprintf("no event received: %v", err)
+	case p.Expect != nil && !p.Expect.MatchString(event.data):
+		result.Status = 0
+		result.Message = fmt.Sprintf("event data %q does not match /%s/", truncateForMessage([]byte(event.data)), p.Expect)
+	}
+	return result, nil
+}
+
+// firstEvent parses the stream until the first event of the wanted type is dispatched.
+func (p *SSEProbe) firstEvent(body io.Reader) (*sseEvent, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 4096), maxSSELineBytes)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// A blank line dispatches the event; events without data are ignored
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+				if p.Event == "" || p.Event == event {
+					return &sseEvent{event: event, data: strings.Join(data, "\n")}, nil
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, errors.New("timed out")
+		}
+		return nil, err
+	}
+	return nil, errors.New("stream closed")
+}
@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSSEProbe(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			http.Error(w, "expected an event stream request", http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		fmt.Fprint(w, ": keep-alive\n\nevent: update\ndata: {\"price\": 1}\n\n")
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, "event: heartbeat\ndata: ok\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // Keep the stream open like a real server
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		cfg        SSEConfig
+		wantStatus int
+		wantMsg    string
+	}{
+		{name: "first event", path: "/events", wantStatus: 1},
+		{name: "event type", path: "/events", cfg: SSEConfig{Event: "heartbeat", Expect: "^ok$"}, wantStatus: 1},
+		{name: "data mismatch", path: "/events", cfg: SSEConfig{Expect: "^ok$"}, wantMsg: `event data "{\"price\": 1}" does not match /^ok$/`},
+		{name: "missing event", path: "/events", cfg: SSEConfig{Event: "closed"}, wantMsg: "no event received: timed out"},
+		{name: "not a stream", path: "/json", wantMsg: `content type "application/json" is not text/event-stream`},
+		{name: "bad status", path: "/missing", wantMsg: "status code 404 not accepted by expected_status [200]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe, err := NewProbeFromConfig(ProbeConfig{Name: "sse-test", Type: "sse", URL: server.URL + tt.path, SSE: tt.cfg})
+			if err != nil {
+				t.Fatalf("NewProbeFromConfig failed: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			result, err := probe.Execute(ctx)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Message != tt.wantMsg {
+				t.Fatalf("got status %d message %q, want %d %q", result.Status, result.Message, tt.wantStatus, tt.wantMsg)
+			}
+		})
+	}
+}
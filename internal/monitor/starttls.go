@@ -0,0 +1,251 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// starttlsProtocol describes how a plaintext service is asked to switch to TLS.
+type starttlsProtocol struct {
+	name        string
+	defaultPort string
+	upgrade     func(conn net.Conn) error
+}
+
+// starttlsProtocols maps the target schemes that select a STARTTLS upgrade to their protocol.
+var starttlsProtocols = map[string]starttlsProtocol{
+	"smtp":       {name: "smtp", defaultPort: "25", upgrade: startTLSSMTP},
+	"imap":       {name: "imap", defaultPort: "143", upgrade: startTLSIMAP},
+	"pop3":       {name: "pop3", defaultPort: "110", upgrade: startTLSPOP3},
+	"ftp":        {name: "ftp", defaultPort: "21", upgrade: startTLSFTP},
+	"ldap":       {name: "ldap", defaultPort: "389", upgrade: startTLSLDAP},
+	"postgres":   {name: "postgres", defaultPort: "5432", upgrade: startTLSPostgres},
+	"postgresql": {name: "postgres", defaultPort: "5432", upgrade: startTLSPostgres},
+}
+
+// STARTTLSProtocols returns the sorted protocol names accepted as target schemes.
+func STARTTLSProtocols() []string {
+	names := make([]string, 0, len(starttlsProtocols))
+	for scheme, protocol := range starttlsProtocols {
+		if scheme == protocol.name {
+			names = append(names, scheme)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithSTARTTLS returns target with the scheme of protocol, for targets given as host or
+// host:port with a separately configured STARTTLS protocol. An empty protocol returns
+// target unchanged.
+func WithSTARTTLS(target, protocol string) (string, error) {
+	if protocol == "" {
+		return target, nil
+	}
+	if _, ok := starttlsProtocols[strings.ToLower(protocol)]; !ok {
+		return "", fmt.Errorf("unknown starttls protocol %q (expected one of %s)", protocol, strings.Join(STARTTLSProtocols(), ", "))
+	}
+	if strings.Contains(target, "://") {
+		return "", fmt.Errorf("target %q already has a scheme, starttls only applies to host or host:port targets", target)
+	}
+	return strings.ToLower(protocol) + "://" + target, nil
+}
+
+// startTLSSMTP sends EHLO and STARTTLS (RFC 3207).
+func startTLSSMTP(conn net.Conn) error {
+	tp := textproto.NewConn(conn)
+	if _, _, err := tp.ReadResponse(220); err != nil {
+		return fmt.Errorf("smtp greeting: %w", err)
+	}
+	if _, err := tp.Cmd("EHLO api-monitor"); err != nil {
+		return err
+	}
+	if _, _, err := tp.ReadResponse(250); err != nil {
+		return fmt.Errorf("smtp EHLO: %w", err)
+	}
+	if _, err := tp.Cmd("STARTTLS"); err != nil {
+		return err
+	}
+	if _, _, err := tp.ReadResponse(220); err != nil {
+		return fmt.Errorf("smtp STARTTLS: %w", err)
+	}
+	return nil
+}
+
+// startTLSFTP sends AUTH TLS (RFC 4217).
+func startTLSFTP(conn net.Conn) error {
+	tp := textproto.NewConn(conn)
+	if _, _, err := tp.ReadResponse(220); err != nil {
+		return fmt.Errorf("ftp greeting: %w", err)
+	}
+	if _, err := tp.Cmd("AUTH TLS"); err != nil {
+		return err
+	}
+	if _, _, err := tp.ReadResponse(234); err != nil {
+		return fmt.Errorf("ftp AUTH TLS: %w", err)
+	}
+	return nil
+}
+
+// startTLSIMAP sends a tagged STARTTLS command (RFC 3501), skipping untagged responses.
+func startTLSIMAP(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	greeting, err := readProtocolLine(r)
+	if err != nil {
+		return fmt.Errorf("imap greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("imap greeting: unexpected %q", greeting)
+	}
+	if _, err := io.WriteString(conn, "a1 STARTTLS\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := readProtocolLine(r)
+		if err != nil {
+			return fmt.Errorf("imap STARTTLS: %w", err)
+		}
+		if strings.HasPrefix(line, "a1 ") {
+			if !strings.HasPrefix(line, "a1 OK") {
+				return fmt.Errorf("imap STARTTLS: %q", line)
+			}
+			return nil
+		}
+	}
+}
+
+// startTLSPOP3 sends STLS (RFC 2595).
+func startTLSPOP3(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	greeting, err := readProtocolLine(r)
+	if err != nil {
+		return fmt.Errorf("pop3 greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "+OK") {
+		return fmt.Errorf("pop3 greeting: unexpected %q", greeting)
+	}
+	if _, err := io.WriteString(conn, "STLS\r\n"); err != nil {
+		return err
+	}
+	reply, err := readProtocolLine(r)
+	if err != nil {
+		return fmt.Errorf("pop3 STLS: %w", err)
+	}
+	if !strings.HasPrefix(reply, "+OK") {
+		return fmt.Errorf("pop3 STLS: %q", reply)
+	}
+	return nil
+}
+
+// readProtocolLine reads a CRLF terminated line without its line ending.
+func readProtocolLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ldapStartTLSRequest is the BER encoded LDAPMessage with messageID 1 carrying the StartTLS
+// extended request (RFC 4511 section 4.14, OID 1.3.6.1.4.1.1466.20037).
+var ldapStartTLSRequest = append([]byte{0x30, 0x1d, 0x02, 0x01, 0x01, 0x77, 0x18, 0x80, 0x16},
+	"1.3.6.1.4.1.1466.20037"...)
+
+// startTLSLDAP sends the StartTLS extended operation and checks its result code.
+func startTLSLDAP(conn net.Conn) error {
+	if _, err := conn.Write(ldapStartTLSRequest); err != nil {
+		return err
+	}
+	r := bufio.NewReader(conn)
+	tag, message, err := readBER(r)
+	if err != nil {
+		return fmt.Errorf("ldap StartTLS: %w", err)
+	}
+	if tag != 0x30 {
+		return fmt.Errorf("ldap StartTLS: unexpected response tag 0x%02x", tag)
+	}
+	// LDAPMessage: messageID INTEGER, then the ExtendedResponse [APPLICATION 24]
+	body := bufio.NewReader(bytes.NewReader(message))
+	if tag, _, err = readBER(body); err != nil || tag != 0x02 {
+		return fmt.Errorf("ldap StartTLS: malformed response")
+	}
+	tag, response, err := readBER(body)
+	if err != nil || tag != 0x78 {
+		return fmt.Errorf("ldap StartTLS: unexpected response")
+	}
+	// ExtendedResponse starts with the LDAPResult resultCode ENUMERATED
+	tag, code, err := readBER(bufio.NewReader(bytes.NewReader(response)))
+	if err != nil || tag != 0x0a || len(code) != 1 {
+		return fmt.Errorf("ldap StartTLS: malformed result code")
+	}
+	if code[0] != 0 {
+		return fmt.Errorf("ldap StartTLS: server returned result code %d", code[0])
+	}
+	return nil
+}
+
+// readBER reads one BER element with a definite length and returns its tag and contents.
+func readBER(r *bufio.Reader) (byte, []byte, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 {
+			return 0, nil, errors.New("unsupported BER length")
+		}
+		length = 0
+		for range n {
+			b, err := r.ReadByte()
+			if err != nil {
+				return 0, nil, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxTCPResponseBytes {
+		return 0, nil, errors.New("BER element too large")
+	}
+	contents := make([]byte, length)
+	if _, err := io.ReadFull(r, contents); err != nil {
+		return 0, nil, err
+	}
+	return tag, contents, nil
+}
+
+// postgresSSLRequestCode is the protocol code of the PostgreSQL SSLRequest message.
+const postgresSSLRequestCode = 80877103
+
+// startTLSPostgres sends an SSLRequest and expects the server to accept it with 'S'.
+func startTLSPostgres(conn net.Conn) error {
+	request := binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, 8), postgresSSLRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("postgres SSLRequest: %w", err)
+	}
+	switch reply[0] {
+	case 'S':
+		return nil
+	case 'N':
+		return errors.New("postgres SSLRequest: server does not accept SSL connections")
+	default:
+		return fmt.Errorf("postgres SSLRequest: unexpected reply 0x%02x", reply[0])
+	}
+}
@@ -0,0 +1,171 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestSTARTTLSServer accepts one connection, runs the plaintext part of a protocol with
+// dialogue and then performs a TLS handshake if dialogue returns true.
+func startTestSTARTTLSServer(t *testing.T, cert tls.Certificate, dialogue func(conn net.Conn, r *bufio.Reader) bool) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if dialogue(conn, bufio.NewReader(conn)) {
+			tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}}).Handshake()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// expectLine reads a line and reports whether it is want.
+func expectLine(r *bufio.Reader, want string) bool {
+	line, err := r.ReadString('\n')
+	return err == nil && strings.TrimRight(line, "\r\n") == want
+}
+
+func TestInspectCertificateChain_STARTTLS(t *testing.T) {
+	cert := newTestCertificate(t, "starttls.test")
+
+	tests := []struct {
+		scheme   string
+		dialogue func(conn net.Conn, r *bufio.Reader) bool
+		wantErr  string
+	}{
+		{scheme: "smtp", dialogue: func(conn net.Conn, r *bufio.Reader) bool {
+			io.WriteString(conn, "220-mail.test ESMTP\r\n220 ready\r\n")
+			if !expectLine(r, "EHLO api-monitor") {
+				return false
+			}
+			io.WriteString(conn, "250-mail.test\r\n250 STARTTLS\r\n")
+			if !expectLine(r, "STARTTLS") {
+				return false
+			}
+			io.WriteString(conn, "220 go ahead\r\n")
+			return true
+		}},
+		{scheme: "imap", dialogue: func(conn net.Conn, r *bufio.Reader) bool {
+			io.WriteString(conn, "* OK IMAP4rev1 ready\r\n")
+			if !expectLine(r, "a1 STARTTLS") {
+				return false
+			}
+			io.WriteString(conn, "* CAPABILITY IMAP4rev1\r\na1 OK begin TLS\r\n")
+			return true
+		}},
+		{scheme: "pop3", dialogue: func(conn net.Conn, r *bufio.Reader) bool {
+			io.WriteString(conn, "+OK POP3 ready\r\n")
+			if !expectLine(r, "STLS") {
+				return false
+			}
+			io.WriteString(conn, "+OK begin TLS\r\n")
+			return true
+		}},
+		{scheme: "ftp", dialogue: func(conn net.Conn, r *bufio.Reader) bool {
+			io.WriteString(conn, "220 FTP ready\r\n")
+			if !expectLine(r, "AUTH TLS") {
+				return false
+			}
+			io.WriteString(conn, "234 AUTH TLS ok\r\n")
+			return true
+		}},
+		{scheme: "ldap", dialogue: func(conn net.Conn, r *bufio.Reader) bool {
+			request := make([]byte, len(ldapStartTLSRequest))
+			if _, err := io.ReadFull(r, request); err != nil || !bytes.Equal(request, ldapStartTLSRequest) {
+				return false
+			}
+			// ExtendedResponse with success, using long form lengths like OpenLDAP
+			conn.Write([]byte{0x30, 0x84, 0, 0, 0, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x84, 0, 0, 0, 0x03, 0x0a, 0x01, 0x00})
+			return true
+		}},
+		{scheme: "postgres", dialogue: func(conn net.Conn, r *bufio.Reader) bool {
+			request := make([]byte, 8)
+			if _, err := io.ReadFull(r, request); err != nil || !bytes.Equal(request, []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}) {
+				return false
+			}
+			conn.Write([]byte("S"))
+			return true
+		}},
+		{scheme: "postgres", wantErr: "server does not accept SSL connections", dialogue: func(conn net.Conn, r *bufio.Reader) bool {
+			io.ReadFull(r, make([]byte, 8))
+			conn.Write([]byte("N"))
+			return false
+		}},
+		{scheme: "smtp", wantErr: "smtp STARTTLS: 454", dialogue: func(conn net.Conn, r *bufio.Reader) bool {
+			io.WriteString(conn, "220 ready\r\n")
+			r.ReadString('\n')
+			io.WriteString(conn, "250 mail.test\r\n")
+			r.ReadString('\n')
+			io.WriteString(conn, "454 TLS not available\r\n")
+			return false
+		}},
+	}
+	for _, tt := range tests {
+		name := tt.scheme
+		if tt.wantErr != "" {
+			name += " rejected"
+		}
+		t.Run(name, func(t *testing.T) {
+			address := startTestSTARTTLSServer(t, cert.tls, tt.dialogue)
+			chain, err := InspectCertificateChain(tt.scheme+"://"+address, 5*time.Second)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InspectCertificateChain failed: %v", err)
+			}
+			if len(chain) != 1 || chain[0].Subject != "CN=starttls.test" {
+				t.Fatalf("unexpected chain %+v", chain)
+			}
+		})
+	}
+}
+
+func TestNormalizeHostPort_STARTTLS(t *testing.T) {
+	tests := []struct {
+		target, wantHost, wantPort, wantSTARTTLS string
+	}{
+		{"smtp://mail.example.com", "mail.example.com", "25", "smtp"},
+		{"smtp://mail.example.com:587", "mail.example.com", "587", "smtp"},
+		{"postgresql://db.example.com", "db.example.com", "5432", "postgres"},
+		{"LDAP://dir.example.com", "dir.example.com", "389", "ldap"},
+		{"https://api.example.com", "api.example.com", "443", ""},
+	}
+	for _, tt := range tests {
+		host, port, _, starttls, err := normalizeHostPort(tt.target)
+		if err != nil || host != tt.wantHost || port != tt.wantPort || starttls != tt.wantSTARTTLS {
+			t.Errorf("normalizeHostPort(%q) = %s, %s, %q, %v", tt.target, host, port, starttls, err)
+		}
+	}
+	if _, _, _, _, err := normalizeHostPort("gopher://example.com"); err == nil {
+		t.Error("expected an error for an unsupported scheme")
+	}
+
+	if target, err := WithSTARTTLS("mail.example.com:587", "SMTP"); err != nil || target != "smtp://mail.example.com:587" {
+		t.Errorf("WithSTARTTLS = %q, %v", target, err)
+	}
+	if _, err := WithSTARTTLS("https://mail.example.com", "smtp"); err == nil {
+		t.Error("expected an error for a target that already has a scheme")
+	}
+	if _, err := WithSTARTTLS("mail.example.com", "xmpp"); err == nil {
+		t.Error("expected an error for an unknown protocol")
+	}
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// defaultHTTPStatusRules is used by http probes that do not set expected_status.
+	defaultHTTPStatusRules = []string{"2xx", "3xx"}
+	// defaultHealthStatusRules is used by StatusCheckProbe: 400/401/403 prove the service
+	// is up and enforcing auth.
+	defaultHealthStatusRules = []string{"2xx", "3xx", "400", "401", "403"}
+)
+
+// statusRule is a single inclusive range of HTTP status codes, optionally negated.
+type statusRule struct {
+	min, max int
+	negate   bool
+}
+
+// StatusMatcher decides whether an HTTP status code counts as "up".
+// A code is accepted when it matches at least one positive rule (or there are only
+// negative rules) and matches none of the negative rules.
+type StatusMatcher struct {
+	rules []statusRule
+	specs []string
+}
+
+// ParseStatusMatcher parses rules such as "200", "2xx", "200-299" and "!5xx".
+func ParseStatusMatcher(specs []string) (*StatusMatcher, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("at least one expected status rule is required")
+	}
+	m := &StatusMatcher{specs: specs}
+	for _, spec := range specs {
+		rule, err := parseStatusRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		m.rules = append(m.rules, rule)
+	}
+	return m, nil
+}
+
+// mustParseStatusMatcher is ParseStatusMatcher for built-in rule sets.
+func mustParseStatusMatcher(specs []string) *StatusMatcher {
+	m, err := ParseStatusMatcher(specs)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// parseStatusRule parses a single rule.
+func parseStatusRule(spec string) (statusRule, error) {
+	var rule statusRule
+	s := strings.ToLower(strings.TrimSpace(spec))
+	if strings.HasPrefix(s, "!") {
+		rule.negate = true
+		s = strings.TrimSpace(s[1:])
+	}
+
+	switch {
+	case len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5':
+		class := int(s[0]-'0') * 100
+		rule.min, rule.max = class, class+99
+	case strings.Contains(s, "-"):
+		lo, hi, _ := strings.Cut(s, "-")
+		min, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		max, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || min > max {
+			return rule, fmt.Errorf("invalid expected status range %q", spec)
+		}
+		rule.min, rule.max = min, max
+	default:
+		code, err := strconv.Atoi(s)
+		if err != nil {
+			return rule, fmt.Errorf("invalid expected status %q (use e.g. 200, 2xx, 200-299 or !5xx)", spec)
+		}
+		rule.min, rule.max = code, code
+	}
+
+	if rule.min < 100 || rule.max > 599 {
+		return rule, fmt.Errorf("expected status %q is outside 100-599", spec)
+	}
+	return rule, nil
+}
+
+// Match reports whether code is accepted by the rules.
+func (m *StatusMatcher) Match(code int) bool {
+	matchedPositive, hasPositive := false, false
+	for _, r := range m.rules {
+		inRange := code >= r.min && code <= r.max
+		if r.negate {
+			if inRange {
+				return false
+			}
+			continue
+		}
+		hasPositive = true
+		if inRange {
+			matchedPositive = true
+		}
+	}
+	return matchedPositive || !hasPositive
+}
+
+// String returns the rules as configured, e.g. "[2xx 401 !5xx]".
+func (m *StatusMatcher) String() string {
+	return "[" + strings.Join(m.specs, " ") + "]"
+}
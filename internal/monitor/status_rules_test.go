@@ -0,0 +1,34 @@
+package monitor
+
+import "testing"
+
+func TestStatusMatcher(t *testing.T) {
+	tests := []struct {
+		rules    []string
+		code     int
+		accepted bool
+	}{
+		{[]string{"2xx"}, 204, true},
+		{[]string{"2xx"}, 301, false},
+		{[]string{"2xx", "401"}, 401, true},
+		{[]string{"200-299", "!204"}, 204, false},
+		{[]string{"200-299", "!204"}, 200, true},
+		{[]string{"!5xx"}, 404, true},
+		{[]string{"!5xx"}, 503, false},
+	}
+	for _, tt := range tests {
+		m, err := ParseStatusMatcher(tt.rules)
+		if err != nil {
+			t.Fatalf("ParseStatusMatcher(%v) failed: %v", tt.rules, err)
+		}
+		if got := m.Match(tt.code); got != tt.accepted {
+			t.Errorf("%v.Match(%d) = %v, want %v", tt.rules, tt.code, got, tt.accepted)
+		}
+	}
+
+	for _, bad := range []string{"abc", "6xx", "299-200", "99"} {
+		if _, err := ParseStatusMatcher([]string{bad}); err == nil {
+			t.Errorf("expected error for rule %q", bad)
+		}
+	}
+}
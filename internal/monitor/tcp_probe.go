@@ -0,0 +1,138 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"time"
+)
+
+// maxTCPResponseBytes caps how much of a TCP response is read while waiting for the expected pattern.
+const maxTCPResponseBytes = 64 << 10
+
+// TCPConfig defines the connection made by a "tcp" probe.
+// Without send and expect the probe only checks that the port accepts connections.
+type TCPConfig struct {
+	Address string `yaml:"address"` // host:port to dial
+	Send    string `yaml:"send"`    // Optional payload written after connecting, e.g. "PING\r\n"
+	Expect  string `yaml:"expect"`  // Optional regular expression the response (or banner) must match
+}
+
+// TCPResult carries the TCP specific outcome of a tcp probe.
+type TCPResult struct {
+	ConnectLatency float64 // Time to establish the connection in seconds
+}
+
+// TCPProbe is an implementation of ProbeExecutor that checks a plain TCP service.
+type TCPProbe struct {
+	Name    string
+	Address string
+	Send    []byte
+	Expect  *regexp.Regexp
+}
+
+// newTCPProbeFromConfig builds a TCPProbe for the "tcp" probe type.
+func newTCPProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if err := rejectProxy(cfg.Proxy, "tcp"); err != nil {
+		return nil, err
+	}
+	tc := cfg.TCP
+	if tc.Address == "" {
+		return nil, fmt.Errorf("tcp.address is required")
+	}
+	host, port, err := net.SplitHostPort(tc.Address)
+	if err != nil || host == "" || port == "" {
+		return nil, fmt.Errorf("invalid tcp.address %q: expected host:port", tc.Address)
+	}
+
+	probe := &TCPProbe{
+		Name:    cfg.Name,
+		Address: tc.Address,
+		Send:    []byte(tc.Send),
+	}
+	if tc.Expect != "" {
+		if probe.Expect, err = regexp.Compile(tc.Expect); err != nil {
+			return nil, fmt.Errorf("invalid tcp.expect %q: %w", tc.Expect, err)
+		}
+	}
+	return probe, nil
+}
+
+// Execute implements the ProbeExecutor interface: it connects, optionally sends the payload and
+// waits for the response to match the expected pattern.
+func (p *TCPProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	start := time.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.Address)
+	connectLatency := time.Since(start).Seconds()
+	if err != nil {
+		err = fmt.Errorf("tcp connect to %s failed: %w", p.Address, err)
+		return NewProbeResult(p.Name, 0, connectLatency, 0, err), err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Unblock reads as soon as the probe is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if len(p.Send) > 0 {
+		if _, err := conn.Write(p.Send); err != nil {
+			err = fmt.Errorf("tcp write to %s failed: %w", p.Address, err)
+			return NewProbeResult(p.Name, 0, time.Since(start).Seconds(), 0, err), err
+		}
+	}
+
+	var message string
+	if p.Expect != nil {
+		message = p.awaitResponse(conn)
+	}
+
+	result := NewProbeResult(p.Name, 1, time.Since(start).Seconds(), 0, nil)
+	result.TCP = &TCPResult{ConnectLatency: connectLatency}
+	if message != "" {
+		result.Status = 0
+		result.Message = message
+	}
+	return result, nil
+}
+
+// awaitResponse reads from conn until the data matches p.Expect and returns why it did not, if it did not.
+func (p *TCPProbe) awaitResponse(conn net.Conn) string {
+	buf := make([]byte, 0, 4096)
+	for len(buf) < maxTCPResponseBytes {
+		if len(buf) == cap(buf) {
+			buf = append(buf, 0)[:len(buf)]
+		}
+		n, err := conn.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if p.Expect.Match(buf) {
+			return ""
+		}
+		if err != nil {
+			reason := err.Error()
+			switch {
+			case errors.Is(err, io.EOF):
+				reason = "connection closed"
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				reason = "timed out"
+			}
+			return fmt.Sprintf("response %q does not match /%s/ (%s)", truncateForMessage(buf), p.Expect, reason)
+		}
+	}
+	return fmt.Sprintf("response does not match /%s/ within %d bytes", p.Expect, maxTCPResponseBytes)
+}
+
+// truncateForMessage shortens data for use in a probe message.
+func truncateForMessage(data []byte) string {
+	const max = 128
+	if len(data) > max {
+		return string(data[:max]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,103 @@
+package monitor
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestTCPServer greets each client with a banner and answers PING with +PONG.
+func startTestTCPServer(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("220 test ready\r\n"))
+				line, err := bufio.NewReader(conn).ReadString('\n')
+				if err == nil && strings.TrimSpace(line) == "PING" {
+					conn.Write([]byte("+PONG\r\n"))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestTCPProbe(t *testing.T) {
+	address := startTestTCPServer(t)
+
+	tests := []struct {
+		name       string
+		tcp        TCPConfig
+		wantStatus int
+		wantMsg    string
+	}{
+		{name: "connect only", tcp: TCPConfig{Address: address}, wantStatus: 1},
+		{name: "banner", tcp: TCPConfig{Address: address, Expect: `^220 `}, wantStatus: 1},
+		{name: "request response", tcp: TCPConfig{Address: address, Send: "PING\r\n", Expect: `\+PONG`}, wantStatus: 1},
+		{
+			name:    "unexpected response",
+			tcp:     TCPConfig{Address: address, Send: "QUIT\r\n", Expect: `\+PONG`},
+			wantMsg: `response "220 test ready\r\n" does not match /\+PONG/ (connection closed)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe, err := NewProbeFromConfig(ProbeConfig{Name: "tcp-test", Type: "tcp", TCP: tt.tcp})
+			if err != nil {
+				t.Fatalf("NewProbeFromConfig failed: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			result, err := probe.Execute(ctx)
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Message != tt.wantMsg {
+				t.Fatalf("got status %d message %q, want %d %q", result.Status, result.Message, tt.wantStatus, tt.wantMsg)
+			}
+			if result.TCP == nil || result.TCP.ConnectLatency <= 0 || result.TCP.ConnectLatency > result.Latency {
+				t.Fatalf("unexpected connect latency %+v (total %v)", result.TCP, result.Latency)
+			}
+		})
+	}
+}
+
+func TestTCPProbe_ConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	probe, err := NewProbeFromConfig(ProbeConfig{Name: "tcp-test", Type: "tcp", TCP: TCPConfig{Address: address}})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	result, err := probe.Execute(context.Background())
+	if err == nil || result.Status != 0 {
+		t.Fatalf("expected a connection error, got status %d, err %v", result.Status, err)
+	}
+}
+
+func TestNewTCPProbeFromConfig_Invalid(t *testing.T) {
+	for _, tc := range []TCPConfig{{}, {Address: "db.example.com"}, {Address: "db:5432", Expect: "("}} {
+		if _, err := newTCPProbeFromConfig(ProbeConfig{Name: "tcp-test", TCP: tc}); err == nil {
+			t.Errorf("expected an error for %+v", tc)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// tlsVersions maps the accepted min_version values to their crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSConfig defines the TLS settings a probe uses to reach its target.
+// CertFile and KeyFile together enable mutual TLS. The server certificate is verified
+// unless InsecureSkipVerify is set.
+type TLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`              // Use TLS (where the protocol also allows plaintext)
+	CAFile             string `yaml:"ca_file"`              // PEM bundle used instead of the system roots
+	CertFile           string `yaml:"cert_file"`            // Client certificate for mTLS
+	KeyFile            string `yaml:"key_file"`             // Client key for mTLS
+	ServerName         string `yaml:"server_name"`          // Overrides the name used for SNI and verification
+	MinVersion         string `yaml:"min_version"`          // Lowest accepted version: 1.0, 1.1, 1.2 (default) or 1.3
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // Skip server certificate verification
+}
+
+// TLSVerificationError reports that the server certificate of a probe target was rejected,
+// as opposed to the target being unreachable.
+type TLSVerificationError struct {
+	Err error
+}
+
+func (e *TLSVerificationError) Error() string {
+	return "tls verification failed: " + e.Err.Error()
+}
+
+func (e *TLSVerificationError) Unwrap() error {
+	return e.Err
+}
+
+// classifyTLSError returns err as a *TLSVerificationError when it was caused by the server
+// certificate failing verification, and err unchanged otherwise.
+func classifyTLSError(err error) error {
+	var verifyErr *tls.CertificateVerificationError
+	var hostnameErr x509.HostnameError
+	var authorityErr x509.UnknownAuthorityError
+	var invalidErr x509.CertificateInvalidError
+	switch {
+	case err == nil, errors.As(err, new(*TLSVerificationError)):
+		return err
+	case errors.As(err, &verifyErr), errors.As(err, &hostnameErr),
+		errors.As(err, &authorityErr), errors.As(err, &invalidErr):
+		return &TLSVerificationError{Err: err}
+	}
+	return err
+}
+
+// newTLSClientConfig builds a crypto/tls client configuration from cfg.
+func newTLSClientConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.MinVersion != "" {
+		version, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown tls.min_version %q (expected 1.0, 1.1, 1.2 or 1.3)", cfg.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls.ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls.ca_file %s contains no PEM certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	switch {
+	case cfg.CertFile != "" && cfg.KeyFile != "":
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tls client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case cfg.CertFile != "" || cfg.KeyFile != "":
+		return nil, fmt.Errorf("tls.cert_file and tls.key_file must be set together")
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,172 @@
+package monitor
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate is a self-signed certificate for 127.0.0.1 and localhost written to disk.
+type testCertificate struct {
+	certFile, keyFile string
+	tls               tls.Certificate
+}
+
+// newTestKey generates a P-256 key for test certificates.
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+// issueTestCertificate signs template with parent/parentKey, or self-signs it when parent is nil.
+// Every test certificate of the package, self-signed or part of a chain, is issued here.
+func issueTestCertificate(t *testing.T, template, parent *x509.Certificate, key crypto.Signer, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("create certificate %s: %v", template.Subject.CommonName, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// newTestCertificate creates a self-signed certificate valid for 127.0.0.1 and localhost.
+func newTestCertificate(t *testing.T, commonName string) testCertificate {
+	t.Helper()
+	key := newTestKey(t)
+	cert := issueTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, nil, key, nil)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	c := testCertificate{
+		certFile: filepath.Join(dir, commonName+".pem"),
+		keyFile:  filepath.Join(dir, commonName+"-key.pem"),
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(c.certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if c.tls, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNewTLSClientConfig(t *testing.T) {
+	cert := newTestCertificate(t, "client")
+
+	cfg, err := newTLSClientConfig(TLSConfig{CAFile: cert.certFile, CertFile: cert.certFile, KeyFile: cert.keyFile, ServerName: "svc.internal"})
+	if err != nil {
+		t.Fatalf("newTLSClientConfig failed: %v", err)
+	}
+	if cfg.RootCAs == nil || len(cfg.Certificates) != 1 || cfg.ServerName != "svc.internal" || cfg.InsecureSkipVerify {
+		t.Fatalf("unexpected TLS config: %+v", cfg)
+	}
+
+	if _, err := newTLSClientConfig(TLSConfig{CertFile: cert.certFile}); err == nil {
+		t.Error("expected an error for a certificate without a key")
+	}
+	if _, err := newTLSClientConfig(TLSConfig{CAFile: cert.keyFile}); err == nil {
+		t.Error("expected an error for a CA file without certificates")
+	}
+}
+
+func TestHTTPProbe_TLSVerification(t *testing.T) {
+	serverCert := newTestCertificate(t, "server")
+	clientCert := newTestCertificate(t, "client")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert.tls.Leaf)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert.tls},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0) // Rejected handshakes are expected
+	server.StartTLS()
+	defer server.Close()
+
+	mTLS := TLSConfig{CAFile: serverCert.certFile, CertFile: clientCert.certFile, KeyFile: clientCert.keyFile}
+	run := func(tlsConfig TLSConfig) (ProbeResult, error) {
+		probe, err := NewProbeFromConfig(ProbeConfig{Name: "mtls", Type: "http", URL: server.URL, TLS: tlsConfig})
+		if err != nil {
+			t.Fatalf("NewProbeFromConfig failed: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return probe.Execute(ctx)
+	}
+
+	if result, err := run(mTLS); err != nil || result.Status != 1 {
+		t.Fatalf("expected the mTLS request to succeed, got status %d: %v", result.Status, err)
+	}
+	insecure := TLSConfig{CertFile: clientCert.certFile, KeyFile: clientCert.keyFile, InsecureSkipVerify: true, MinVersion: "1.3"}
+	if result, err := run(insecure); err != nil || result.Status != 1 {
+		t.Fatalf("expected insecure_skip_verify to accept the certificate, got status %d: %v", result.Status, err)
+	}
+
+	wrongName := mTLS
+	wrongName.ServerName = "api.example.com"
+	for name, cfg := range map[string]TLSConfig{"system roots": {}, "server name": wrongName} {
+		_, err := run(cfg)
+		if !errors.As(err, new(*TLSVerificationError)) {
+			t.Errorf("%s: expected a TLS verification error, got %v", name, err)
+		}
+	}
+
+	// A rejected client certificate is a handshake failure, not a verification failure
+	noClientCert := TLSConfig{CAFile: serverCert.certFile}
+	if _, err := run(noClientCert); err == nil || errors.As(err, new(*TLSVerificationError)) {
+		t.Errorf("expected a non-verification error without a client certificate, got %v", err)
+	}
+
+	if _, err := NewProbeFromConfig(ProbeConfig{Name: "old", Type: "http", URL: server.URL, TLS: TLSConfig{MinVersion: "1.4"}}); err == nil {
+		t.Error("expected an error for an unknown tls.min_version")
+	}
+}
@@ -0,0 +1,338 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// transactionVariablePattern matches a {{variable}} reference in a transaction step.
+// It is distinct from ${ENV} so references survive configuration loading.
+var transactionVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// transactionVariableName matches a valid variable name.
+var transactionVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TransactionStepConfig defines one HTTP request of a "transaction" probe.
+// URL, header values and body may reference variables extracted by earlier steps as {{name}}.
+// Credentials should be given as ${ENV} or file: references, which are redacted from logs and /config.
+type TransactionStepConfig struct {
+	Name           string            `yaml:"name"`
+	Method         string            `yaml:"method"` // HTTP method, defaults to GET
+	URL            string            `yaml:"url"`
+	Headers        map[string]string `yaml:"headers" secret:"keys"`
+	Body           string            `yaml:"body" secret:"true"`
+	ExpectedStatus []string          `yaml:"expected_status"` // Defaults to 2xx and 3xx
+	Assertions     []AssertionConfig `yaml:"assertions"`
+	Extract        []ExtractConfig   `yaml:"extract"`
+}
+
+// ExtractConfig captures a value from a step response into a variable.
+//
+//	type: json_path | header | regex
+type ExtractConfig struct {
+	Name    string `yaml:"name"` // Variable name, referenced as {{name}}
+	Type    string `yaml:"type"`
+	Path    string `yaml:"path"`    // JSONPath for json_path, e.g. $.data.token
+	Header  string `yaml:"header"`  // Response header for header
+	Pattern string `yaml:"pattern"` // Regular expression for regex; the first capture group is used if there is one
+}
+
+// StepResult is the outcome of a single transaction step.
+type StepResult struct {
+	Name       string
+	Status     int     // 0 for FAILED, 1 for SUCCESS
+	Latency    float64 // Latency in seconds
+	StatusCode int
+	Skipped    bool   // An earlier step failed, the step was not run
+	Message    string // Why the step failed, empty when it passed
+}
+
+// transactionStep is a compiled TransactionStepConfig.
+type transactionStep struct {
+	name           string
+	method         string
+	url            string
+	headers        map[string]string
+	body           string
+	expectedStatus *StatusMatcher
+	assertions     []*bodyAssertion
+	extract        []*extractor
+}
+
+// extractor is a compiled ExtractConfig.
+type extractor struct {
+	name    string
+	kind    string
+	path    []jsonPathStep
+	header  string
+	pattern *regexp.Regexp
+}
+
+// TransactionProbe is an implementation of ProbeExecutor that runs a sequence of HTTP requests
+// sharing cookies and extracted variables. The transaction stops at the first failed step.
+type TransactionProbe struct {
+	Client *http.Client
+	Name   string
+	steps  []*transactionStep
+}
+
+// newTransactionProbeFromConfig builds a TransactionProbe for the "transaction" probe type.
+func newTransactionProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	if len(cfg.Steps) == 0 {
+		return nil, fmt.Errorf("at least one step is required")
+	}
+	proxy, err := newProxyFunc(cfg.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := newTLSClientConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	probe := &TransactionProbe{
+		Client: &http.Client{
+			Transport: &http.Transport{Proxy: proxy, TLSClientConfig: tlsConfig, DisableKeepAlives: true},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		Name: cfg.Name,
+	}
+
+	defined := map[string]bool{}
+	stepNames := map[string]bool{}
+	for i, sc := range cfg.Steps {
+		step, err := compileTransactionStep(sc, defined)
+		if err != nil {
+			return nil, fmt.Errorf("steps[%d]: %w", i, err)
+		}
+		if stepNames[step.name] {
+			return nil, fmt.Errorf("steps[%d]: duplicate step name %q", i, step.name)
+		}
+		stepNames[step.name] = true
+		probe.steps = append(probe.steps, step)
+	}
+	return probe, nil
+}
+
+// compileTransactionStep validates a step. defined holds the variables extracted by earlier
+// steps and is extended with the variables of this one.
+func compileTransactionStep(cfg TransactionStepConfig, defined map[string]bool) (*transactionStep, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("step name is required")
+	}
+	step := &transactionStep{
+		name:           cfg.Name,
+		method:         http.MethodGet,
+		url:            cfg.URL,
+		headers:        cfg.Headers,
+		body:           cfg.Body,
+		expectedStatus: mustParseStatusMatcher(defaultHTTPStatusRules),
+	}
+
+	// The url is only checked when it is fully static
+	if !transactionVariablePattern.MatchString(cfg.URL) {
+		if err := validateHTTPURL(cfg.URL); err != nil {
+			return nil, err
+		}
+	}
+	references := []string{cfg.URL, cfg.Body}
+	for _, v := range cfg.Headers {
+		references = append(references, v)
+	}
+	for _, s := range references {
+		for _, m := range transactionVariablePattern.FindAllStringSubmatch(s, -1) {
+			if !defined[m[1]] {
+				return nil, fmt.Errorf("variable {{%s}} is not extracted by an earlier step", m[1])
+			}
+		}
+	}
+
+	if cfg.Method != "" {
+		if !httpMethodPattern.MatchString(cfg.Method) {
+			return nil, fmt.Errorf("invalid HTTP method %q", cfg.Method)
+		}
+		step.method = strings.ToUpper(cfg.Method)
+	}
+	if len(cfg.ExpectedStatus) > 0 {
+		matcher, err := ParseStatusMatcher(cfg.ExpectedStatus)
+		if err != nil {
+			return nil, err
+		}
+		step.expectedStatus = matcher
+	}
+	assertions, err := compileAssertions(cfg.Assertions)
+	if err != nil {
+		return nil, err
+	}
+	step.assertions = assertions
+
+	for i, ec := range cfg.Extract {
+		e, err := compileExtractor(ec)
+		if err != nil {
+			return nil, fmt.Errorf("extract[%d]: %w", i, err)
+		}
+		step.extract = append(step.extract, e)
+		defined[e.name] = true
+	}
+	return step, nil
+}
+
+// compileExtractor validates a single extraction.
+func compileExtractor(cfg ExtractConfig) (*extractor, error) {
+	if !transactionVariableName.MatchString(cfg.Name) {
+		return nil, fmt.Errorf("invalid variable name %q", cfg.Name)
+	}
+	e := &extractor{name: cfg.Name, kind: strings.ToLower(cfg.Type)}
+	switch e.kind {
+	case "json_path":
+		path, err := parseJSONPath(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		e.path = path
+	case "header":
+		if cfg.Header == "" {
+			return nil, fmt.Errorf("header extraction requires a header name")
+		}
+		e.header = cfg.Header
+	case "regex":
+		pattern, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", cfg.Pattern, err)
+		}
+		e.pattern = pattern
+	default:
+		return nil, fmt.Errorf("unknown extract type %q (expected json_path, header or regex)", cfg.Type)
+	}
+	return e, nil
+}
+
+// Execute implements the ProbeExecutor interface. Step failures, including transport errors,
+// are reported through the result so every step's outcome is exported.
+func (p *TransactionProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	jar, _ := cookiejar.New(nil)
+	client := *p.Client
+	client.Jar = jar
+
+	vars := map[string]string{}
+	result := NewProbeResult(p.Name, 1, 0, 0, nil)
+	for _, step := range p.steps {
+		if result.Status == 0 {
+			result.Steps = append(result.Steps, StepResult{Name: step.name, Skipped: true, Message: "skipped"})
+			continue
+		}
+		stepResult, err := step.run(ctx, &client, vars)
+		result.Steps = append(result.Steps, stepResult)
+		result.Latency += stepResult.Latency
+		result.StatusCode = stepResult.StatusCode
+		if stepResult.Status == 0 {
+			result.Status = 0
+			result.Error = err
+			result.Message = fmt.Sprintf("step %s failed: %s", step.name, stepResult.Message)
+		}
+	}
+	return result, nil
+}
+
+// run performs the step and stores its extracted variables in vars.
+func (s *transactionStep) run(ctx context.Context, client *http.Client, vars map[string]string) (StepResult, error) {
+	result := StepResult{Name: s.name}
+	fail := func(err error) (StepResult, error) {
+		result.Message = err.Error()
+		return result, err
+	}
+
+	var body io.Reader
+	if s.body != "" {
+		body = strings.NewReader(expandTransactionVariables(s.body, vars))
+	}
+	req, err := http.NewRequestWithContext(ctx, s.method, expandTransactionVariables(s.url, vars), body)
+	if err != nil {
+		return fail(err)
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, expandTransactionVariables(v, vars))
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Latency = time.Since(start).Seconds()
+		return fail(classifyTLSError(err))
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxAssertionBodyBytes))
+	result.Latency = time.Since(start).Seconds()
+	result.StatusCode = resp.StatusCode
+	if err != nil {
+		return fail(fmt.Errorf("failed to read response body: %w", err))
+	}
+
+	verdict := httpStatusResult(s.name, s.expectedStatus, result.Latency, resp.StatusCode)
+	applyAssertionResults(&verdict, evaluateAssertions(s.assertions, respBody))
+	if verdict.Status == 0 {
+		result.Message = verdict.Message
+		return result, nil
+	}
+
+	for _, e := range s.extract {
+		value, err := e.extract(resp, respBody)
+		if err != nil {
+			result.Message = fmt.Sprintf("extract %s: %v", e.name, err)
+			return result, nil
+		}
+		vars[e.name] = value
+	}
+	result.Status = 1
+	return result, nil
+}
+
+// extract pulls the variable value out of a response.
+func (e *extractor) extract(resp *http.Response, body []byte) (string, error) {
+	switch e.kind {
+	case "header":
+		value := resp.Header.Get(e.header)
+		if value == "" {
+			return "", fmt.Errorf("header %s not present", e.header)
+		}
+		return value, nil
+	case "regex":
+		m := e.pattern.FindSubmatch(body)
+		if m == nil {
+			return "", fmt.Errorf("body does not match /%s/", e.pattern)
+		}
+		if len(m) > 1 {
+			return string(m[1]), nil
+		}
+		return string(m[0]), nil
+	default:
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", fmt.Errorf("body is not valid JSON: %v", err)
+		}
+		value, found := evalJSONPath(doc, e.path)
+		if !found {
+			return "", fmt.Errorf("%s does not exist", jsonPathString(e.path))
+		}
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		return formatJSONValue(value, true), nil
+	}
+}
+
+// expandTransactionVariables replaces {{name}} references with their values.
+func expandTransactionVariables(s string, vars map[string]string) string {
+	return transactionVariablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		return vars[transactionVariablePattern.FindStringSubmatch(ref)[1]]
+	})
+}
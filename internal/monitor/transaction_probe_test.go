@@ -0,0 +1,132 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLoginServer issues a token and a session cookie on POST /login and only serves
+// GET /me to clients presenting both.
+func newTestLoginServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		w.Header().Set("X-Request-Id", "req-42")
+		w.Write([]byte(`{"data": {"token": "t0k3n", "user": {"id": 7}}}`))
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if r.Header.Get("Authorization") != "Bearer t0k3n" || err != nil || cookie.Value != "s1" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id": ` + r.PathValue("id") + `, "trace": "` + r.Header.Get("X-Trace") + `"}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTransactionProbe(t *testing.T) {
+	server := newTestLoginServer(t)
+	steps := func(authorization string) []TransactionStepConfig {
+		return []TransactionStepConfig{
+			{
+				Name:   "login",
+				Method: "post",
+				URL:    server.URL + "/login",
+				Extract: []ExtractConfig{
+					{Name: "token", Type: "json_path", Path: "$.data.token"},
+					{Name: "user_id", Type: "json_path", Path: "$.data.user.id"},
+					{Name: "request_id", Type: "header", Header: "X-Request-Id"},
+				},
+			},
+			{
+				Name:       "profile",
+				URL:        server.URL + "/users/{{user_id}}",
+				Headers:    map[string]string{"Authorization": authorization, "X-Trace": "{{ request_id }}"},
+				Assertions: []AssertionConfig{{Type: "contains", Value: `"trace": "req-42"`}},
+				Extract:    []ExtractConfig{{Name: "id", Type: "regex", Pattern: `"id": (\d+)`}},
+			},
+			{Name: "logout", URL: server.URL + "/users/{{id}}", Headers: map[string]string{"Authorization": authorization}},
+		}
+	}
+
+	run := func(cfg []TransactionStepConfig) ProbeResult {
+		probe, err := NewProbeFromConfig(ProbeConfig{Name: "login-flow", Type: "transaction", Steps: cfg})
+		if err != nil {
+			t.Fatalf("NewProbeFromConfig failed: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		result, err := probe.Execute(ctx)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result
+	}
+
+	result := run(steps("Bearer {{token}}"))
+	if result.Status != 1 || len(result.Steps) != 3 {
+		t.Fatalf("expected the transaction to pass, got %d: %s %+v", result.Status, result.Message, result.Steps)
+	}
+	for _, step := range result.Steps {
+		if step.Status != 1 || step.StatusCode != http.StatusOK || step.Latency <= 0 {
+			t.Errorf("unexpected step result %+v", step)
+		}
+	}
+
+	result = run(steps("Bearer wrong"))
+	if result.Status != 0 || !strings.HasPrefix(result.Message, "step profile failed: status code 401") {
+		t.Fatalf("expected the profile step to fail, got %d: %q", result.Status, result.Message)
+	}
+	if s := result.Steps; s[0].Status != 1 || s[1].Status != 0 || s[1].StatusCode != 401 || !s[2].Skipped {
+		t.Fatalf("unexpected step results %+v", s)
+	}
+}
+
+func TestNewTransactionProbeFromConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []TransactionStepConfig
+		wantErr string
+	}{
+		{name: "no steps", wantErr: "at least one step is required"},
+		{
+			name:    "undefined variable",
+			steps:   []TransactionStepConfig{{Name: "me", URL: "https://api.example.com/me", Headers: map[string]string{"Authorization": "{{token}}"}}},
+			wantErr: "steps[0]: variable {{token}} is not extracted by an earlier step",
+		},
+		{
+			name: "variable used in the step that extracts it",
+			steps: []TransactionStepConfig{{
+				Name: "login", URL: "https://api.example.com/{{token}}",
+				Extract: []ExtractConfig{{Name: "token", Type: "header", Header: "X-Token"}},
+			}},
+			wantErr: "variable {{token}} is not extracted by an earlier step",
+		},
+		{
+			name:    "bad extract type",
+			steps:   []TransactionStepConfig{{Name: "login", URL: "https://api.example.com", Extract: []ExtractConfig{{Name: "t", Type: "xpath"}}}},
+			wantErr: `steps[0]: extract[0]: unknown extract type "xpath"`,
+		},
+		{
+			name:    "duplicate step",
+			steps:   []TransactionStepConfig{{Name: "a", URL: "https://a.example.com"}, {Name: "a", URL: "https://b.example.com"}},
+			wantErr: `steps[1]: duplicate step name "a"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTransactionProbeFromConfig(ProbeConfig{Name: "tx", Steps: tt.steps})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
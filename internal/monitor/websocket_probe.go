@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// WebSocketConfig defines the exchange made by a "websocket" probe against its ws:// or wss:// url.
+// Without expect the probe succeeds once the upgrade completes.
+type WebSocketConfig struct {
+	Send   string `yaml:"send" secret:"true"` // Optional text message sent once the connection is open
+	Expect string `yaml:"expect"`             // Optional regular expression a received message must match
+	Origin string `yaml:"origin"`             // Origin header, defaults to the probe url with an http(s) scheme
+}
+
+// WebSocketProbe is an implementation of ProbeExecutor that completes a WebSocket upgrade.
+// Latency is the time until the expected message arrives, or until the upgrade completes
+// when no message is expected.
+type WebSocketProbe struct {
+	Name   string
+	Config *websocket.Config
+	Send   string
+	Expect *regexp.Regexp
+	dial   proxyDialFunc
+}
+
+// newWebSocketProbeFromConfig builds a WebSocketProbe for the "websocket" probe type.
+func newWebSocketProbeFromConfig(cfg ProbeConfig) (ProbeExecutor, error) {
+	location, err := url.Parse(cfg.URL)
+	if err != nil || (location.Scheme != "ws" && location.Scheme != "wss") || location.Host == "" {
+		return nil, fmt.Errorf("invalid url %q: expected ws:// or wss://host", cfg.URL)
+	}
+
+	origin := cfg.WebSocket.Origin
+	if origin == "" {
+		o := *location
+		o.Scheme = map[string]string{"ws": "http", "wss": "https"}[location.Scheme]
+		o.Path, o.RawQuery = "", ""
+		origin = o.String()
+	}
+	wsConfig, err := websocket.NewConfig(cfg.URL, origin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket.origin %q: %w", origin, err)
+	}
+	if wsConfig.TlsConfig, err = newTLSClientConfig(cfg.TLS); err != nil {
+		return nil, err
+	}
+
+	dial, err := newProxyDialer(cfg.Proxy, location.Scheme == "wss")
+	if err != nil {
+		return nil, err
+	}
+
+	probe := &WebSocketProbe{
+		Name:   cfg.Name,
+		Config: wsConfig,
+		Send:   cfg.WebSocket.Send,
+		dial:   dial,
+	}
+	if cfg.WebSocket.Expect != "" {
+		if probe.Expect, err = regexp.Compile(cfg.WebSocket.Expect); err != nil {
+			return nil, fmt.Errorf("invalid websocket.expect %q: %w", cfg.WebSocket.Expect, err)
+		}
+	}
+	return probe, nil
+}
+
+// Execute implements the ProbeExecutor interface.
+func (p *WebSocketProbe) Execute(ctx context.Context) (ProbeResult, error) {
+	start := time.Now()
+	conn, err := p.connect(ctx)
+	if err != nil {
+		err = fmt.Errorf("websocket upgrade failed: %w", classifyTLSError(err))
+		return NewProbeResult(p.Name, 0, time.Since(start).Seconds(), 0, err), err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Unblock the handshake and reads as soon as the probe is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	ws, err := websocket.NewClient(p.Config, conn)
+	if err != nil {
+		err = fmt.Errorf("websocket upgrade failed: %w", err)
+		return NewProbeResult(p.Name, 0, time.Since(start).Seconds(), 0, err), err
+	}
+
+	if p.Send != "" {
+		if err := websocket.Message.Send(ws, p.Send); err != nil {
+			err = fmt.Errorf("websocket send failed: %w", err)
+			return NewProbeResult(p.Name, 0, time.Since(start).Seconds(), http.StatusSwitchingProtocols, err), err
+		}
+	}
+
+	var message string
+	if p.Expect != nil {
+		message = p.awaitMessage(ws)
+	}
+	result := NewProbeResult(p.Name, 1, time.Since(start).Seconds(), http.StatusSwitchingProtocols, nil)
+	if message != "" {
+		result.Status = 0
+		result.Message = message
+	}
+	return result, nil
+}
+
+// connect opens the connection the upgrade request is sent on, through the probe's proxy if
+// one is set, and completes the TLS handshake for wss:// urls.
+func (p *WebSocketProbe) connect(ctx context.Context) (net.Conn, error) {
+	location := p.Config.Location
+	port := location.Port()
+	if port == "" {
+		port = map[string]string{"ws": "80", "wss": "443"}[location.Scheme]
+	}
+	conn, err := p.dial(ctx, net.JoinHostPort(location.Hostname(), port))
+	if err != nil || location.Scheme != "wss" {
+		return conn, err
+	}
+
+	tlsConfig := p.Config.TlsConfig.Clone()
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = location.Hostname()
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// awaitMessage reads messages until one matches p.Expect and returns why none did, if none did.
+func (p *WebSocketProbe) awaitMessage(ws *websocket.Conn) string {
+	var last string
+	for {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			reason := err.Error()
+			switch {
+			case errors.Is(err, io.EOF):
+				reason = "connection closed"
+			case errors.Is(err, os.ErrDeadlineExceeded):
+				reason = "timed out"
+			}
+			if last == "" {
+				return fmt.Sprintf("no message matching /%s/ received (%s)", p.Expect, reason)
+			}
+			return fmt.Sprintf("last message %q does not match /%s/ (%s)", truncateForMessage([]byte(last)), p.Expect, reason)
+		}
+		if p.Expect.MatchString(msg) {
+			return ""
+		}
+		last = msg
+	}
+}
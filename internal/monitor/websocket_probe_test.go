@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketProbe(t *testing.T) {
+	// The server greets every client and answers "ping" with "pong".
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		websocket.Message.Send(ws, "welcome")
+		var msg string
+		for websocket.Message.Receive(ws, &msg) == nil {
+			if msg == "ping" {
+				websocket.Message.Send(ws, "pong")
+			}
+		}
+	}))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		cfg        WebSocketConfig
+		wantStatus int
+		wantMsg    string
+		wantErr    bool
+	}{
+		{name: "upgrade only", url: wsURL, wantStatus: 1},
+		{name: "request reply", url: wsURL, cfg: WebSocketConfig{Send: "ping", Expect: "^pong$"}, wantStatus: 1},
+		{name: "no matching reply", url: wsURL, cfg: WebSocketConfig{Expect: "^pong$"}, wantMsg: `last message "welcome" does not match /^pong$/ (timed out)`},
+		{name: "plain http endpoint", url: "ws" + strings.TrimPrefix(plain.URL, "http"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe, err := NewProbeFromConfig(ProbeConfig{Name: "ws-test", Type: "websocket", URL: tt.url, WebSocket: tt.cfg})
+			if err != nil {
+				t.Fatalf("NewProbeFromConfig failed: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			result, err := probe.Execute(ctx)
+			if tt.wantErr {
+				if err == nil || result.Status != 0 {
+					t.Fatalf("expected the upgrade to fail, got status %d", result.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Message != tt.wantMsg {
+				t.Fatalf("got status %d message %q, want %d %q", result.Status, result.Message, tt.wantStatus, tt.wantMsg)
+			}
+			if result.StatusCode != http.StatusSwitchingProtocols {
+				t.Fatalf("status code = %d, want 101", result.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNewWebSocketProbeFromConfig_RequiresWebSocketURL(t *testing.T) {
+	if _, err := newWebSocketProbeFromConfig(ProbeConfig{Name: "ws-test", URL: "https://example.com/socket"}); err == nil {
+		t.Fatal("expected an error for an https:// url")
+	}
+}
+
+func TestWebSocketProbe_ThroughProxy(t *testing.T) {
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		websocket.Message.Send(ws, "hello")
+	}))
+	defer server.Close()
+	proxyURL, tunnels := startTestConnectProxy(t, "")
+
+	probe, err := NewProbeFromConfig(ProbeConfig{
+		Name:      "ws-proxy-test",
+		Type:      "websocket",
+		URL:       "ws" + strings.TrimPrefix(server.URL, "http"),
+		Proxy:     ProxyConfig{URL: proxyURL},
+		WebSocket: WebSocketConfig{Expect: "^hello$"},
+	})
+	if err != nil {
+		t.Fatalf("NewProbeFromConfig failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result, err := probe.Execute(ctx)
+	if err != nil || result.Status != 1 {
+		t.Fatalf("expected the probe to succeed through the proxy, got status %d (err=%v, message=%q)", result.Status, err, result.Message)
+	}
+	if tunnels.Load() != 1 {
+		t.Fatalf("expected one CONNECT tunnel, got %d", tunnels.Load())
+	}
+}
+
+func TestNewProbeFromConfig_RejectsUnsupportedProxy(t *testing.T) {
+	for _, cfg := range []ProbeConfig{
+		{Name: "tcp-test", Type: "tcp", TCP: TCPConfig{Address: "localhost:6379"}},
+		{Name: "dns-test", Type: "dns", DNS: DNSConfig{Query: "example.com"}},
+		{Name: "grpc-test", Type: "grpc", GRPC: GRPCConfig{Address: "localhost:9090"}},
+	} {
+		cfg.Proxy = ProxyConfig{URL: "socks5://proxy.example.com:1080"}
+		if _, err := NewProbeFromConfig(cfg); err == nil || !strings.Contains(err.Error(), "proxy is not supported") {
+			t.Errorf("%s: expected the proxy to be rejected, got %v", cfg.Type, err)
+		}
+	}
+}
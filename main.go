@@ -2,14 +2,19 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
-	"time"
 
 	"api-monitor/internal/cronutils"
 	"api-monitor/internal/monitor" // Import our internal package
 )
 
 func main() {
+	// Subcommands are dispatched before flag parsing so they can define their own flags
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(runValidate(os.Args[2:]))
+	}
+
 	var envOverride string
 	var configPath string
 
@@ -23,38 +28,63 @@ func main() {
 	}
 
 	// Load configuration from the specified path, defaulting to "configs/application.yaml" if not provided via --config.
-	cfg, err := monitor.LoadYAMLConfig(configPath)
+	// Overlays for the selected environment (--env or current_env) are merged over the base file.
+	cfg, err := monitor.LoadYAMLConfigForEnv(configPath, envOverride)
 	if err != nil {
 		monitor.FmtLog(monitor.LogLevelError, "Error loading configuration: %v", err)
 		os.Exit(1)
 	}
 
-	// Parse durations from configuration
-	apiTimeout, err := time.ParseDuration(cfg.MonitorConfig.APITimeout)
-	if err != nil {
-		monitor.FmtLog(monitor.LogLevelError, "Error parsing api_timeout: %v", err)
-		os.Exit(1)
-	}
-	apiProbeInterval, err := time.ParseDuration(cfg.MonitorConfig.APIProbeInterval)
+	// Parse durations and apply the environment override
+	settings, err := monitor.NewSettings(cfg, envOverride)
 	if err != nil {
-		monitor.FmtLog(monitor.LogLevelError, "Error parsing api_probe_interval: %v", err)
+		monitor.FmtLog(monitor.LogLevelError, "Error parsing configuration: %v", err)
 		os.Exit(1)
 	}
 
-	currentEnv := cfg.MonitorConfig.CurrentEnv
-	// If an environment is provided via command line, it overrides the one in the config file
-	if envOverride != "" {
-		currentEnv = envOverride
+	monitor.FmtLog(monitor.LogLevelInfo, "Monitoring environment: %s", settings.CurrentEnv)
+	monitor.FmtLog(monitor.LogLevelInfo, "%d API probes defined in %s", len(settings.Probes), configPath)
+	for _, p := range settings.Probes {
+		monitor.FmtLog(monitor.LogLevelInfo, "Probe %s (type=%s)", p.Name, p.Type)
 	}
-
-	metricsPort := cfg.MonitorConfig.MetricsPort
-
-	monitor.FmtLog(monitor.LogLevelInfo, "Monitoring environment: %s", currentEnv)
-	monitor.FmtLog(monitor.LogLevelInfo, "APIs are hardcoded in monitor.StartMonitoring")
+	// The full configuration, secrets redacted, is served at /config on the metrics port
+	monitor.FmtLog(monitor.LogLevelInfo, "Effective configuration hash: %s", settings.Hash)
 
 	// Initialize and start the cron job
 	cronutils.InitCronJob()
 
 	// Start the monitoring service
-	monitor.StartMonitoring(apiTimeout, apiProbeInterval, currentEnv, metricsPort, cfg.MonitorConfig.AWS)
+	monitor.StartMonitoring(settings, configPath, envOverride)
+}
+
+// runValidate implements "api-monitor validate --config <file> [--env <env>]". It prints every problem
+// found in the configuration file and returns the process exit code.
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := fs.String("config", "configs/application.yaml", "Path to the YAML configuration file to validate")
+	env := fs.String("env", "", "Environment whose overlays are merged before validating (defaults to current_env)")
+	fs.Parse(args)
+
+	problems, err := monitor.ValidateConfigFile(*configPath, *env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *configPath, err)
+		return 2
+	}
+	errorCount := 0
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+		if !p.Warning {
+			errorCount++
+		}
+	}
+	if errorCount == 0 {
+		if len(problems) > 0 {
+			fmt.Printf("%s: configuration is valid, %d warning(s)\n", *configPath, len(problems))
+		} else {
+			fmt.Printf("%s: configuration is valid\n", *configPath)
+		}
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "%d problem(s) found in %s\n", errorCount, *configPath)
+	return 1
 }
@@ -1,3 +1,5 @@
 : ".\dist\probe.exe" -url https://www.baidu.com -url https://www.google.com -url https://www.lala.com
 
 ".\dist\probe.exe" -config ./configs/application.yaml 
+
+".\dist\probe.exe" validate --config ./configs/application.yaml